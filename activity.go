@@ -0,0 +1,169 @@
+package gobls
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+var (
+	IDLE_COLOR = color.RGBA{40, 60, 200, 255} // conductive pixels of nets that never toggled
+)
+
+// TrackActivity starts or stops counting the state changes of nets and
+// gates in Simulate. Starting clears the counters.
+func (simulator *Simulator) TrackActivity(enabled bool) {
+	simulator.tracking = enabled
+	if enabled {
+		simulator.ResetActivity()
+	}
+}
+
+func (simulator *Simulator) TrackingActivity() bool {
+	return simulator.tracking
+}
+
+func (simulator *Simulator) ResetActivity() {
+	simulator.netToggles = make([]int, len(simulator.states))
+	simulator.gateToggles = make([]int, len(simulator.gates))
+	simulator.prevStates = append(simulator.prevStates[:0], simulator.states...)
+}
+
+// countToggles compares the net states with those of the previous step.
+func (simulator *Simulator) countToggles() {
+	for i, state := range simulator.states {
+		if state != simulator.prevStates[i] {
+			simulator.netToggles[i]++
+			simulator.prevStates[i] = state
+		}
+	}
+}
+
+// NetToggles returns how often the net under a pixel changed state while
+// tracking.
+func (simulator *Simulator) NetToggles(x, y int) int {
+	wire := simulator.wireAt(x, y)
+	if wire < 0 || simulator.netToggles == nil {
+		return 0
+	}
+
+	return simulator.netToggles[wire]
+}
+
+// GateToggles returns the number of output changes per gate center.
+func (simulator *Simulator) GateToggles() map[Pin]int {
+	toggles := make(map[Pin]int)
+	if simulator.gateToggles == nil {
+		return toggles
+	}
+
+	for i, g := range simulator.gates {
+		center := g.center()
+		toggles[Pin{center.x, center.y}] = simulator.gateToggles[i]
+	}
+
+	return toggles
+}
+
+// IdleNets returns the first pixel of every net that never changed state
+// while tracking.
+func (simulator *Simulator) IdleNets() []Pin {
+	idle := make([]Pin, 0)
+	if simulator.netToggles == nil {
+		return idle
+	}
+
+	seen := make(map[int]bool)
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.wireAt(x, y)
+			if wire < 0 || seen[wire] {
+				continue
+			}
+			seen[wire] = true
+
+			if simulator.netToggles[wire] == 0 {
+				idle = append(idle, Pin{x, y})
+			}
+		}
+	}
+
+	return idle
+}
+
+// MaxToggles returns the highest toggle count of the nets.
+func (simulator *Simulator) MaxToggles() int {
+	max := 0
+	for _, toggles := range simulator.netToggles {
+		if toggles > max {
+			max = toggles
+		}
+	}
+
+	return max
+}
+
+// PerPixelToggles calls f for every pixel with the toggle count of its net,
+// -1 for insulation.
+func (simulator *Simulator) PerPixelToggles(f func(x, y, toggles int)) {
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.wireAt(x, y)
+			switch {
+			case wire < 0:
+				f(x, y, -1)
+			case simulator.netToggles == nil:
+				f(x, y, 0)
+			default:
+				f(x, y, simulator.netToggles[wire])
+			}
+		}
+	}
+}
+
+// HeatColor maps a toggle count to a color from dark red to white on a
+// logarithmic scale up to max. Nets that never toggled are IDLE_COLOR.
+func HeatColor(toggles, max int) color.RGBA {
+	if toggles <= 0 {
+		return IDLE_COLOR
+	}
+
+	heat := 1.0
+	if max > 1 {
+		heat = math.Log(float64(toggles)+1) / math.Log(float64(max)+1)
+	}
+
+	// red, then yellow, then white
+	channel := func(offset float64) uint8 {
+		value := (heat*3 - offset) * 255
+		if value < 0 {
+			return 0
+		}
+		if value > 255 {
+			return 255
+		}
+		return uint8(value)
+	}
+
+	r := channel(0)
+	if r < 80 {
+		r = 80
+	}
+	return color.RGBA{r, channel(1), channel(2), 255}
+}
+
+// Heatmap renders the toggle counts of the nets, insulation black.
+func (simulator *Simulator) Heatmap() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, simulator.width, simulator.height))
+
+	max := simulator.MaxToggles()
+	simulator.PerPixelToggles(func(x, y, toggles int) {
+		if toggles < 0 {
+			img.SetRGBA(x, y, color.RGBA{0, 0, 0, 255})
+		} else {
+			img.SetRGBA(x, y, HeatColor(toggles, max))
+		}
+	})
+
+	return img
+}
@@ -0,0 +1,57 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func TestActivity(t *testing.T) {
+	// ring oscillator and a separate wire
+	b := builder.New(16, 10)
+	b.Not(3, 2, gobls.DIR_RIGHT)
+	b.Not(6, 2, gobls.DIR_RIGHT)
+	b.Not(9, 2, gobls.DIR_RIGHT)
+	b.Wire(10, 2, 12, 5)
+	b.Wire(12, 5, 2, 5)
+	b.Wire(2, 5, 2, 2)
+	b.Wire(1, 8, 14, 8)
+
+	simulator := loadBuilder(b)
+	simulator.TrackActivity(true)
+	for i := 0; i < 100; i++ {
+		simulator.Simulate()
+	}
+
+	if simulator.NetToggles(12, 5) == 0 || simulator.NetToggles(5, 2) == 0 {
+		t.Errorf("oscillator nets toggled %d and %d times", simulator.NetToggles(12, 5), simulator.NetToggles(5, 2))
+	}
+	for center, toggles := range simulator.GateToggles() {
+		if toggles == 0 {
+			t.Errorf("gate at %d,%d never toggled", center.X, center.Y)
+		}
+	}
+
+	idle := simulator.IdleNets()
+	if len(idle) != 1 || idle[0] != (gobls.Pin{X: 1, Y: 8}) {
+		t.Errorf("idle nets %v", idle)
+	}
+
+	heatmap := simulator.Heatmap()
+	if heatmap.RGBAAt(5, 8) != gobls.IDLE_COLOR {
+		t.Errorf("idle wire colored %v", heatmap.RGBAAt(5, 8))
+	}
+	if c := heatmap.RGBAAt(12, 5); c == gobls.IDLE_COLOR || c.R == 0 {
+		t.Errorf("toggling wire colored %v", c)
+	}
+	if c := heatmap.RGBAAt(0, 0); c.R != 0 || c.G != 0 || c.B != 0 {
+		t.Errorf("insulation colored %v", c)
+	}
+
+	simulator.TrackActivity(false)
+	simulator.Simulate()
+	if simulator.TrackingActivity() {
+		t.Error("still tracking")
+	}
+}
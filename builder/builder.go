@@ -0,0 +1,229 @@
+// Package builder draws circuit bitmaps in code.
+//
+// Coordinates are pixels. Components are stamped from small templates
+// drawn for the right pointing orientation and rotated as needed; a stamp
+// overwrites every pixel of its template, insulation included.
+package builder
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+var palette = color.Palette{
+	color.RGBA{0, 0, 0, 255},
+	color.RGBA{255, 255, 255, 255},
+}
+
+type Builder struct {
+	width  int
+	height int
+	pixels []bool
+
+	manifest *gobls.Manifest
+}
+
+// Ports are the absolute positions of a component's input and output pixels.
+type Ports struct {
+	In  []image.Point
+	Out image.Point
+}
+
+type template struct {
+	rows []string
+	in   []image.Point
+	out  image.Point
+}
+
+var (
+	notTemplate = template{
+		rows: []string{
+			"##.",
+			"#.#",
+			"##.",
+		},
+		in:  []image.Point{{0, 1}},
+		out: image.Point{2, 1},
+	}
+
+	crossingTemplate = template{
+		rows: []string{
+			".#.",
+			"#.#",
+			".#.",
+		},
+	}
+
+	andTemplate = template{
+		rows: []string{
+			"..........",
+			"..##......",
+			".##.##....",
+			"..##.##...",
+			".....#.##.",
+			"..##.##...",
+			".##.##....",
+			"..##......",
+			"..........",
+		},
+		in:  []image.Point{{1, 2}, {1, 6}},
+		out: image.Point{8, 4},
+	}
+
+	orTemplate = template{
+		rows: []string{
+			"..........",
+			".#####....",
+			".....####.",
+			".#####....",
+			"..........",
+		},
+		in:  []image.Point{{1, 1}, {1, 3}},
+		out: image.Point{8, 2},
+	}
+)
+
+func New(width, height int) *Builder {
+	builder := new(Builder)
+	builder.width = width
+	builder.height = height
+	builder.pixels = make([]bool, width*height)
+	builder.manifest = gobls.NewManifest()
+
+	return builder
+}
+
+func (builder *Builder) Size() (int, int) {
+	return builder.width, builder.height
+}
+
+// Set makes a pixel conductive or insulating. Pixels outside the image are
+// ignored.
+func (builder *Builder) Set(x, y int, conductive bool) {
+	if x < 0 || y < 0 || x >= builder.width || y >= builder.height {
+		return
+	}
+
+	builder.pixels[x+y*builder.width] = conductive
+}
+
+func (builder *Builder) Get(x, y int) bool {
+	if x < 0 || y < 0 || x >= builder.width || y >= builder.height {
+		return false
+	}
+
+	return builder.pixels[x+y*builder.width]
+}
+
+// Wire draws a wire from (x0, y0) to (x1, y1), horizontally first and then
+// vertically when the points are not aligned.
+func (builder *Builder) Wire(x0, y0, x1, y1 int) {
+	for x := min(x0, x1); x <= max(x0, x1); x++ {
+		builder.Set(x, y0, true)
+	}
+	for y := min(y0, y1); y <= max(y0, y1); y++ {
+		builder.Set(x1, y, true)
+	}
+}
+
+// Connect draws a wire between two points.
+func (builder *Builder) Connect(a, b image.Point) {
+	builder.Wire(a.X, a.Y, b.X, b.Y)
+}
+
+// Crossing stamps a wire crossing centered at (x, y). Wires reaching the
+// four arms pass through without connecting.
+func (builder *Builder) Crossing(x, y int) {
+	builder.stamp(x-1, y-1, crossingTemplate, gobls.DIR_RIGHT)
+}
+
+// Not stamps a NOT gate centered at (x, y) with its output pointing to dir.
+func (builder *Builder) Not(x, y, dir int) Ports {
+	return builder.stamp(x-1, y-1, notTemplate, dir)
+}
+
+// And stamps the AND gate construction with its top left corner at (x, y).
+// The 10x9 template is rotated for other directions.
+func (builder *Builder) And(x, y, dir int) Ports {
+	return builder.stamp(x, y, andTemplate, dir)
+}
+
+// Or stamps two wires merging into one with its top left corner at (x, y).
+// It only works as an OR gate when both inputs are driven by gates.
+func (builder *Builder) Or(x, y, dir int) Ports {
+	return builder.stamp(x, y, orTemplate, dir)
+}
+
+// Pin draws a pixel and appends it to the named pin group of the manifest.
+func (builder *Builder) Pin(name string, x, y int) {
+	builder.Set(x, y, true)
+	builder.manifest.Pins[name] = append(builder.manifest.Pins[name], gobls.Pin{X: x, Y: y})
+}
+
+// Manifest returns the labelled pins.
+func (builder *Builder) Manifest() *gobls.Manifest {
+	return builder.manifest
+}
+
+// Image returns a two color image LoadImage understands.
+func (builder *Builder) Image() *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, builder.width, builder.height), palette)
+
+	for i, conductive := range builder.pixels {
+		if conductive {
+			img.Pix[i] = 1
+		}
+	}
+
+	return img
+}
+
+func (builder *Builder) stamp(x, y int, t template, dir int) Ports {
+	width := len(t.rows[0])
+	height := len(t.rows)
+
+	for ty, row := range t.rows {
+		for tx, c := range row {
+			p := rotate(image.Point{tx, ty}, width, height, dir)
+			builder.Set(x+p.X, y+p.Y, c == '#')
+		}
+	}
+
+	ports := Ports{}
+	for _, in := range t.in {
+		ports.In = append(ports.In, rotate(in, width, height, dir).Add(image.Point{x, y}))
+	}
+	ports.Out = rotate(t.out, width, height, dir).Add(image.Point{x, y})
+
+	return ports
+}
+
+// rotate maps a point of a right pointing template of the given size.
+func rotate(p image.Point, width, height, dir int) image.Point {
+	switch dir {
+	case gobls.DIR_DOWN:
+		return image.Point{height - 1 - p.Y, p.X}
+	case gobls.DIR_LEFT:
+		return image.Point{width - 1 - p.X, height - 1 - p.Y}
+	case gobls.DIR_UP:
+		return image.Point{p.Y, width - 1 - p.X}
+	default:
+		return p
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
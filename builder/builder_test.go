@@ -0,0 +1,95 @@
+package builder_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+const settleSteps = 20
+
+func settle(simulator *gobls.Simulator) {
+	for i := 0; i < settleSteps; i++ {
+		simulator.Simulate()
+	}
+}
+
+func TestNotOrientations(t *testing.T) {
+	for _, dir := range []int{gobls.DIR_UP, gobls.DIR_RIGHT, gobls.DIR_DOWN, gobls.DIR_LEFT} {
+		b := builder.New(5, 5)
+		ports := b.Not(2, 2, dir)
+		b.Pin("in", ports.In[0].X, ports.In[0].Y)
+		b.Pin("out", ports.Out.X, ports.Out.Y)
+
+		simulator := gobls.NewSimulator()
+		simulator.LoadImage(b.Image())
+		pins := b.Manifest().Pins
+
+		for _, value := range []bool{false, true, false} {
+			simulator.WriteBus(pins["in"], boolValue(value))
+			settle(simulator)
+
+			if got := simulator.ReadBus(pins["out"]) == 1; got == value {
+				t.Errorf("dir %d: not(%v) = %v", dir, value, got)
+			}
+		}
+	}
+}
+
+func TestAnd(t *testing.T) {
+	for _, dir := range []int{gobls.DIR_UP, gobls.DIR_RIGHT, gobls.DIR_DOWN, gobls.DIR_LEFT} {
+		b := builder.New(10, 10)
+		ports := b.And(0, 0, dir)
+		b.Pin("a", ports.In[0].X, ports.In[0].Y)
+		b.Pin("b", ports.In[1].X, ports.In[1].Y)
+		b.Pin("y", ports.Out.X, ports.Out.Y)
+
+		simulator := gobls.NewSimulator()
+		simulator.LoadImage(b.Image())
+		pins := b.Manifest().Pins
+
+		for i := 0; i < 4; i++ {
+			simulator.WriteBus(pins["a"], uint64(i&1))
+			simulator.WriteBus(pins["b"], uint64(i>>1))
+			settle(simulator)
+
+			want := uint64(0)
+			if i == 3 {
+				want = 1
+			}
+			if got := simulator.ReadBus(pins["y"]); got != want {
+				t.Errorf("dir %d: and(%d, %d) = %d", dir, i&1, i>>1, got)
+			}
+		}
+	}
+}
+
+func TestCrossing(t *testing.T) {
+	b := builder.New(7, 7)
+	b.Crossing(3, 3)
+	b.Pin("west", 0, 3)
+	b.Wire(0, 3, 2, 3)
+	b.Wire(4, 3, 6, 3)
+	b.Pin("east", 6, 3)
+	b.Wire(3, 0, 3, 2)
+	b.Pin("north", 3, 0)
+	b.Wire(3, 4, 3, 6)
+	b.Pin("south", 3, 6)
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(b.Image())
+	pins := b.Manifest().Pins
+
+	simulator.WriteBus(pins["west"], 1)
+	if simulator.ReadBus(pins["east"]) != 1 || simulator.ReadBus(pins["north"]) != 0 || simulator.ReadBus(pins["south"]) != 0 {
+		t.Error("crossing connects the wrong wires")
+	}
+}
+
+func boolValue(value bool) uint64 {
+	if value {
+		return 1
+	}
+	return 0
+}
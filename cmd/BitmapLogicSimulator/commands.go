@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+// commands run without opening a window
+var commands = map[string]func(args []string) error{}
+
+func runCommand(name string, args []string) int {
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		printCommands()
+		return 2
+	}
+
+	err := command(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	return 0
+}
+
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: BitmapLogicSimulator [command] [flags] image.png")
+	fmt.Fprintln(os.Stderr, "without a command the viewer is started using config.json")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%s\n", name)
+	}
+}
+
+// decodeImage reads an image, or flattens a layout file ending in .json.
+func decodeImage(imgFileName string) (image.Image, error) {
+	img, _, err := decodeDesign(imgFileName)
+	return img, err
+}
+
+// decodeDesign is decodeImage also returning the flattened layout, nil for
+// plain images.
+func decodeDesign(imgFileName string) (image.Image, *gobls.Design, error) {
+	if strings.ToLower(filepath.Ext(imgFileName)) == ".json" {
+		layout, err := gobls.LoadLayout(imgFileName)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		design, err := layout.Flatten()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", imgFileName, err)
+		}
+
+		return design.Image, design, nil
+	}
+
+	img, err := decodePNG(imgFileName)
+	return img, nil, err
+}
+
+func decodePNG(imgFileName string) (image.Image, error) {
+	imgFile, err := os.Open(imgFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer imgFile.Close()
+
+	img, _, err := image.Decode(imgFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", imgFileName, err)
+	}
+
+	return img, nil
+}
+
+// colorSources are the sources attached from the current image's reserved
+// colors, replaced when the image is reloaded.
+var colorSources []*gobls.Source
+
+func attachColorSources(simulator *gobls.Simulator, img image.Image, clockPeriod int) {
+	for _, source := range colorSources {
+		simulator.Detach(source)
+	}
+
+	colorSources = gobls.ColorSources(img)
+	for _, source := range colorSources {
+		if source.Mode == gobls.SOURCE_CLOCK && clockPeriod >= 2 {
+			source.Period = clockPeriod
+			source.Duty = clockPeriod / 2
+		}
+
+		simulator.Attach(source)
+	}
+}
+
+// loadSimulator extracts an image and reads the pins of a manifest. The
+// manifest is optional. Its devices are not attached, attaching truncates
+// their output files; commands simulating them call attachDevices.
+func loadSimulator(imgFileName, manifestFileName string) (*gobls.Simulator, *gobls.Manifest, error) {
+	simulator, manifest, _, err := loadDesignSimulator(imgFileName, manifestFileName)
+	return simulator, manifest, err
+}
+
+// loadDesignSimulator is loadSimulator also returning the flattened layout,
+// nil for plain images. The pins of the layout's instances are added to the
+// manifest.
+func loadDesignSimulator(imgFileName, manifestFileName string) (*gobls.Simulator, *gobls.Manifest, *gobls.Design, error) {
+	img, design, err := decodeDesign(imgFileName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(img)
+
+	manifest := gobls.NewManifest()
+	if manifestFileName != "" {
+		manifest, err = gobls.LoadManifest(manifestFileName)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if design != nil {
+		for name, bus := range design.Manifest.Pins {
+			if _, ok := manifest.Pins[name]; !ok {
+				manifest.Pins[name] = bus
+			}
+		}
+	}
+
+	return simulator, manifest, design, nil
+}
+
+// attachDevices attaches the devices of a manifest, detaching the attached
+// ones again on failure.
+func attachDevices(simulator *gobls.Simulator, manifest *gobls.Manifest) error {
+	err := manifest.Attach(simulator)
+	if err != nil {
+		simulator.DetachAll()
+	}
+
+	return err
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+)
+
+func init() {
+	commands["compile"] = compile
+}
+
+// compile writes an image's circuit as a Go source file.
+func compile(args []string) error {
+	flags := flag.NewFlagSet("compile", flag.ContinueOnError)
+	packageName := flags.String("pkg", "circuit", "package name of the generated file")
+	outFileName := flags.String("o", "", "output file, stdout when empty")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one image file")
+	}
+
+	simulator, _, err := loadSimulator(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+
+	if *outFileName == "" {
+		return simulator.GenerateGo(os.Stdout, *packageName)
+	}
+
+	file, err := os.Create(*outFileName)
+	if err != nil {
+		return err
+	}
+
+	err = simulator.GenerateGo(file, *packageName)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func init() {
+	commands["cone"] = cone
+}
+
+// cone lists the fan-in or fan-out cone of a net, or traces its state back
+// to the sources after simulating, e.g.
+//
+//	BitmapLogicSimulator cone -manifest cpu.json -at carry -trace -steps 5000 cpu.png
+func cone(args []string) error {
+	flags := flag.NewFlagSet("cone", flag.ContinueOnError)
+	manifestFileName := flags.String("manifest", "", "pin manifest")
+	at := flags.String("at", "", "net as x,y or a pin group of the manifest")
+	fanOut := flags.Bool("out", false, "list the fan-out cone instead of the fan-in cone")
+	trace := flags.Bool("trace", false, "simulate with the manifest's devices and trace the state of the net back to its sources")
+	levels := flags.Int("levels", 0, "gates to follow from the net, 0 for all")
+	steps := flags.Int("steps", 1000, "simulation steps before tracing")
+	outFileName := flags.String("o", "", "write an image highlighting the cone")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one image file")
+	}
+
+	simulator, manifest, err := loadSimulator(flags.Arg(0), *manifestFileName)
+	if err != nil {
+		return err
+	}
+	defer simulator.DetachAll()
+
+	pin, err := parseNet(manifest, *at)
+	if err != nil {
+		return err
+	}
+	names := netNames(simulator, manifest)
+
+	if *trace {
+		err = attachDevices(simulator, manifest)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < *steps; i++ {
+			simulator.Simulate()
+		}
+
+		t, ok := simulator.Trace(pin.X, pin.Y)
+		if !ok {
+			return fmt.Errorf("no net at %d,%d", pin.X, pin.Y)
+		}
+
+		for _, gate := range t.Gates {
+			fmt.Printf("gate %d,%d level %d\n", gate.Center.X, gate.Center.Y, gate.Level)
+		}
+		for _, source := range t.Sources {
+			fmt.Printf("source %d,%d %s%s%s\n", source.Pin.X, source.Pin.Y, stateName(source.State),
+				heldName(source.Held), names[netID(simulator, source.Pin)])
+		}
+		fmt.Printf("%s through %d gates from %d sources\n", stateName(t.State), len(t.Gates), len(t.Sources))
+
+		if *outFileName != "" {
+			return saveHighlightImage(*outFileName, simulator.Image(), traceGatePixels(t), PATH_COLOR)
+		}
+		return nil
+	}
+
+	var c *gobls.Cone
+	var ok bool
+	if *fanOut {
+		c, ok = simulator.FanOut(pin.X, pin.Y, *levels)
+	} else {
+		c, ok = simulator.FanIn(pin.X, pin.Y, *levels)
+	}
+	if !ok {
+		return fmt.Errorf("no net at %d,%d", pin.X, pin.Y)
+	}
+
+	for _, gate := range c.Gates {
+		fmt.Printf("gate %d,%d level %d\n", gate.Center.X, gate.Center.Y, gate.Level)
+	}
+	for _, net := range c.Nets {
+		fmt.Printf("net %d,%d%s\n", net.X, net.Y, names[netID(simulator, net)])
+	}
+	fmt.Printf("%d gates, %d nets\n", len(c.Gates), len(c.Nets))
+
+	if *outFileName != "" {
+		return saveHighlightImage(*outFileName, simulator.Image(), conePixels(simulator, c), PATH_COLOR)
+	}
+
+	return nil
+}
+
+// parseNet parses x,y or takes the first pin of a manifest pin group.
+func parseNet(manifest *gobls.Manifest, spec string) (gobls.Pin, error) {
+	fields := strings.Split(spec, ",")
+	if len(fields) == 2 {
+		x, errX := strconv.Atoi(strings.TrimSpace(fields[0]))
+		y, errY := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if errX == nil && errY == nil {
+			return gobls.Pin{X: x, Y: y}, nil
+		}
+	}
+
+	bus, err := manifest.Bus(spec)
+	if err != nil {
+		return gobls.Pin{}, err
+	}
+	if len(bus) == 0 {
+		return gobls.Pin{}, fmt.Errorf("pin group %q is empty", spec)
+	}
+
+	return bus[0], nil
+}
+
+// netNames maps the nets of the manifest's pins to their names, joined and
+// prefixed with a space.
+func netNames(simulator *gobls.Simulator, manifest *gobls.Manifest) map[int]string {
+	groups := make([]string, 0, len(manifest.Pins))
+	for name := range manifest.Pins {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+
+	names := make(map[int]string)
+	for _, group := range groups {
+		bus := manifest.Pins[group]
+		for i, pin := range bus {
+			net, ok := simulator.NetAt(pin.X, pin.Y)
+			if !ok {
+				continue
+			}
+
+			name := group
+			if len(bus) > 1 {
+				name = fmt.Sprintf("%s[%d]", group, i)
+			}
+			names[net.ID] += " " + name
+		}
+	}
+
+	return names
+}
+
+func netID(simulator *gobls.Simulator, pin gobls.Pin) int {
+	net, ok := simulator.NetAt(pin.X, pin.Y)
+	if !ok {
+		return -1
+	}
+
+	return net.ID
+}
+
+func heldName(held bool) string {
+	if held {
+		return " held"
+	}
+	return ""
+}
+
+// conePixels returns the pixels of a cone's nets and gates.
+func conePixels(simulator *gobls.Simulator, c *gobls.Cone) []gobls.Pin {
+	centers := make([]gobls.Pin, len(c.Gates))
+	for i, gate := range c.Gates {
+		centers[i] = gate.Center
+	}
+	pixels := gatePixels(centers)
+
+	for _, net := range c.Nets {
+		mask := simulator.NetMask(net.X, net.Y)
+		if mask == nil {
+			continue
+		}
+
+		for y := mask.Rect.Min.Y; y < mask.Rect.Max.Y; y++ {
+			for x := mask.Rect.Min.X; x < mask.Rect.Max.X; x++ {
+				if mask.AlphaAt(x, y).A != 0 {
+					pixels = append(pixels, gobls.Pin{X: x, Y: y})
+				}
+			}
+		}
+	}
+
+	return pixels
+}
+
+func traceGatePixels(t *gobls.Trace) []gobls.Pin {
+	centers := make([]gobls.Pin, len(t.Gates))
+	for i, gate := range t.Gates {
+		centers[i] = gate.Center
+	}
+
+	return gatePixels(centers)
+}
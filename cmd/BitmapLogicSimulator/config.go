@@ -7,14 +7,38 @@ import (
 
 type Config struct {
 	FileName            string
+	ManifestFileName    string
 	SimulationsPerFrame int
+
+	ColorSources bool // drive pixels painted with the reserved source colors
+	ClockPeriod  int  // period of color clocks in steps
+
+	KeyBindings []KeyBindingConfig
+
+	PathInputs  string // comma separated pin groups for the critical path (C key)
+	PathOutputs string
+
+	ConeLevels int // gates the I and O keys follow from the net, 0 for all
+
+	Breakpoints    []string // conditions over pin groups pausing the simulation
+	Watches        []string // conditions only logged
+	SnapshotPrefix string   // net states written as <prefix><step>.png when a condition fires
+}
+
+// KeyBindingConfig binds a key to a manifest pin group, or to the pin at X, Y
+// when Pins is empty. Momentary bindings are high while the key is held.
+type KeyBindingConfig struct {
+	Key    string
+	Pins   string
+	X, Y   int
+	Toggle bool
 }
 
 func loadConfig(configFileName string) (*Config, error) {
 	configFile, err := os.Open(configFileName)
 	defer configFile.Close()
 	if err != nil {
-		return &Config{"", 5}, nil
+		return &Config{FileName: "", SimulationsPerFrame: 5}, nil
 	}
 
 	decoder := json.NewDecoder(configFile)
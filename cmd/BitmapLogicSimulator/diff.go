@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image/color"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+// colors of the diff image per kind of difference
+var diffColors = map[string]color.RGBA{
+	gobls.DIFF_GATE_ADDED:       {60, 255, 60, 255},
+	gobls.DIFF_GATE_REMOVED:     {255, 60, 60, 255},
+	gobls.DIFF_GATE_REORIENTED:  {255, 255, 60, 255},
+	gobls.DIFF_CROSSING_ADDED:   {60, 160, 60, 255},
+	gobls.DIFF_CROSSING_REMOVED: {160, 60, 60, 255},
+	gobls.DIFF_NET_MERGED:       {255, 160, 40, 255},
+	gobls.DIFF_NET_SPLIT:        {60, 200, 255, 255},
+}
+
+func init() {
+	commands["diff"] = diff
+}
+
+// diff prints how the circuit of the second image differs from the first.
+func diff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	outFileName := flags.String("o", "", "write the second image with changed regions in color")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("expected two image files")
+	}
+
+	a, _, err := loadSimulator(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	b, _, err := loadSimulator(flags.Arg(1), "")
+	if err != nil {
+		return err
+	}
+
+	differences := gobls.Diff(a, b)
+	for _, difference := range differences {
+		fmt.Println(difference)
+	}
+
+	if *outFileName != "" {
+		out := dimmedImage(b.Image())
+
+		// nets first, gates and crossings on top
+		for _, nets := range []bool{true, false} {
+			for _, difference := range differences {
+				isNet := difference.Kind == gobls.DIFF_NET_MERGED || difference.Kind == gobls.DIFF_NET_SPLIT
+				if isNet != nets {
+					continue
+				}
+
+				for _, p := range difference.Pixels {
+					out.SetRGBA(p.X, p.Y, diffColors[difference.Kind])
+				}
+			}
+		}
+
+		err = savePNG(*outFileName, out)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(differences) > 0 {
+		return fmt.Errorf("%d differences", len(differences))
+	}
+
+	return nil
+}
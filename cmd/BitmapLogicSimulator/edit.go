@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/editor"
+)
+
+var document *editor.Document // image shown and simulated, edited in edit mode
+
+var editMode bool
+var editDir = gobls.DIR_RIGHT // orientation of stamped NOT gates
+
+var stroking bool // mouse button held while painting
+var strokeConductive bool
+var strokeLine bool // shift held, draw a straight line on release
+var strokeX, strokeY int
+
+// openDocument makes a new document of a loaded image unless it only is the
+// document saved back.
+func openDocument(imgFileName string, img image.Image) *image.RGBA {
+	if document != nil && document.Matches(img) {
+		return document.Image()
+	}
+
+	if document != nil && document.Modified() {
+		log.Println("image changed on disk, unsaved edits dropped")
+	}
+
+	document = editor.New(img)
+	if strings.ToLower(filepath.Ext(imgFileName)) == ".png" {
+		document.FileName = imgFileName
+	}
+
+	return document.Image()
+}
+
+func toggleEditMode(w *glfw.Window) {
+	editMode = !editMode
+	stroking = false
+	updateTitle(w)
+}
+
+func updateTitle(w *glfw.Window) {
+	title := WINDOW_TITLE
+	if paused {
+		title += fmt.Sprintf(" [paused at step %d]", simulator.Steps())
+	}
+	if editMode {
+		title += " [edit, gate " + dirNames[editDir] + "]"
+	}
+	if document != nil && document.Modified() {
+		title += " *"
+	}
+
+	w.SetTitle(title)
+}
+
+var dirNames = [...]string{"up", "right", "down", "left"}
+
+// handleEditKey runs the edit mode keys and reports whether the key was one.
+func handleEditKey(w *glfw.Window, key glfw.Key, mods glfw.ModifierKey) bool {
+	cursorX, cursorY := w.GetCursorPos()
+	x, y := cursorToImagePoint(w, cursorX, cursorY)
+	changed := image.Rectangle{}
+
+	switch {
+	case key == glfw.KeyZ && mods&glfw.ModControl != 0 && mods&glfw.ModShift != 0,
+		key == glfw.KeyY && mods&glfw.ModControl != 0:
+		changed, _ = document.Redo()
+	case key == glfw.KeyZ && mods&glfw.ModControl != 0:
+		changed, _ = document.Undo()
+	case key == glfw.KeyS && mods&glfw.ModControl != 0:
+		saveDocument()
+	case key == glfw.KeyG:
+		changed = document.Do(editor.Not{X: x, Y: y, Dir: editDir})
+	case key == glfw.KeyX:
+		changed = document.Do(editor.Crossing{X: x, Y: y})
+	case key == glfw.KeyR:
+		editDir = (editDir + 1) % 4
+	case key == glfw.KeyE:
+		toggleEditMode(w)
+	default:
+		return false
+	}
+
+	applyEdit(changed)
+	updateTitle(w)
+
+	return true
+}
+
+func saveDocument() {
+	if document.FileName == "" {
+		log.Println("save : only PNG images can be saved")
+		return
+	}
+
+	err := document.Save()
+	if err != nil {
+		log.Printf("save : %v\n", err)
+		return
+	}
+
+	log.Printf("saved %s\n", document.FileName)
+}
+
+// handleEditMouse paints with the left button and erases with the right,
+// along a straight line with shift held.
+func handleEditMouse(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey, x, y int) bool {
+	if button != glfw.MouseButtonLeft && button != glfw.MouseButtonRight {
+		return false
+	}
+
+	switch action {
+	case glfw.Press:
+		stroking = true
+		strokeConductive = button == glfw.MouseButtonLeft
+		strokeLine = mod&glfw.ModShift != 0
+		strokeX, strokeY = x, y
+
+		if !strokeLine {
+			document.Begin()
+			applyEdit(document.Do(editor.Pixel{X: x, Y: y, Conductive: strokeConductive}))
+		}
+	case glfw.Release:
+		if !stroking {
+			return true
+		}
+		stroking = false
+
+		if strokeLine {
+			applyEdit(document.Do(editor.Line{X0: strokeX, Y0: strokeY, X1: x, Y1: y, Conductive: strokeConductive}))
+		} else {
+			document.End()
+		}
+		updateTitle(w)
+	}
+
+	return true
+}
+
+// editCursorMoved continues a stroke to the cursor.
+func editCursorMoved(x, y int) {
+	if !stroking || strokeLine || (x == strokeX && y == strokeY) {
+		return
+	}
+
+	applyEdit(document.Do(editor.Line{X0: strokeX, Y0: strokeY, X1: x, Y1: y, Conductive: strokeConductive}))
+	strokeX, strokeY = x, y
+}
+
+// applyEdit uploads the changed pixels and extracts them again.
+func applyEdit(changed image.Rectangle) {
+	if changed.Empty() {
+		return
+	}
+
+	region := image.NewRGBA(image.Rect(0, 0, changed.Dx(), changed.Dy()))
+	draw.Draw(region, region.Rect, document.Image(), changed.Min, draw.Src)
+
+	gl.BindTexture(gl.TEXTURE_2D, texId)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(changed.Min.X), int32(changed.Min.Y), int32(changed.Dx()), int32(changed.Dy()),
+		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(region.Pix))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	// no debug images for every stroke
+	dump := simulator.DumpImages
+	simulator.DumpImages = false
+	simulator.UpdateRegion(document.Image(), changed)
+	simulator.DumpImages = dump
+
+	if hasHighlight("path") {
+		showCriticalPath()
+	}
+	updateForceHighlights()
+	updateSelection()
+	updateCone()
+	invalidateTooltip()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func init() {
+	commands["equiv"] = equiv
+}
+
+// equiv checks that two images compute the same outputs from the same
+// inputs.
+func equiv(args []string) error {
+	flags := flag.NewFlagSet("equiv", flag.ContinueOnError)
+	manifestFileName := flags.String("manifest", "", "pin manifest shared by both images")
+	inputs := flags.String("in", "", "comma separated input pin groups")
+	outputs := flags.String("out", "", "comma separated output pin groups")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("expected two image files")
+	}
+	if *manifestFileName == "" {
+		return errors.New("no manifest given")
+	}
+
+	manifest, err := gobls.LoadManifest(*manifestFileName)
+	if err != nil {
+		return err
+	}
+	inputBus, err := manifestBus(manifest, *inputs)
+	if err != nil {
+		return err
+	}
+	outputBus, err := manifestBus(manifest, *outputs)
+	if err != nil {
+		return err
+	}
+	if len(outputBus) == 0 {
+		return errors.New("no output pins")
+	}
+
+	a, _, err := loadSimulator(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	b, _, err := loadSimulator(flags.Arg(1), "")
+	if err != nil {
+		return err
+	}
+
+	result, err := gobls.CheckEquivalence(a, b, inputBus, outputBus)
+	if err != nil {
+		return err
+	}
+
+	if result.Equal {
+		fmt.Printf("equivalent (%s)\n", result.Method)
+		return nil
+	}
+
+	fmt.Printf("different (%s), counterexample:\n", result.Method)
+	printGroups(manifest, *inputs, result.Inputs, "")
+	printGroups(manifest, *outputs, result.OutputsA, flags.Arg(0))
+	printGroups(manifest, *outputs, result.OutputsB, flags.Arg(1))
+
+	return errors.New("circuits differ")
+}
+
+// printGroups prints values of comma separated pin groups, most significant
+// pin first.
+func printGroups(manifest *gobls.Manifest, names string, values []bool, prefix string) {
+	if prefix != "" {
+		prefix += ": "
+	}
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		bus, _ := manifest.Bus(name)
+
+		digits := make([]byte, len(bus))
+		for i := range bus {
+			digits[len(bus)-1-i] = '0'
+			if values[i] {
+				digits[len(bus)-1-i] = '1'
+			}
+		}
+		values = values[len(bus):]
+
+		fmt.Printf("  %s%s = %s\n", prefix, name, digits)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	commands["extract"] = extract
+}
+
+// extract cuts a rectangle out of an image and writes it as a module with
+// a manifest of its boundary pins.
+func extract(args []string) error {
+	flags := flag.NewFlagSet("extract", flag.ContinueOnError)
+	rectSpec := flags.String("rect", "", "region as x,y,width,height")
+	manifestFileName := flags.String("manifest", "", "pin manifest, groups inside the region are kept")
+	outFileName := flags.String("o", "module.png", "output module image")
+	pinsFileName := flags.String("pins", "module.json", "output pin manifest of the module")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one image file")
+	}
+
+	rect, err := parseRect(*rectSpec)
+	if err != nil {
+		return err
+	}
+
+	simulator, manifest, err := loadSimulator(flags.Arg(0), *manifestFileName)
+	if err != nil {
+		return err
+	}
+	defer simulator.DetachAll()
+
+	img, pins, err := simulator.ExtractModule(rect, manifest)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(pins.Pins))
+	for name := range pins.Pins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s\t%v\n", name, pins.Pins[name])
+	}
+
+	err = savePNG(*outFileName, img)
+	if err != nil {
+		return err
+	}
+
+	return pins.Save(*pinsFileName)
+}
+
+// parseRect parses x,y,width,height.
+func parseRect(spec string) (image.Rectangle, error) {
+	fields := strings.Split(spec, ",")
+	if len(fields) != 4 {
+		return image.Rectangle{}, fmt.Errorf("rectangle %q: expected x,y,width,height", spec)
+	}
+
+	values := make([]int, 4)
+	for i, field := range fields {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("rectangle %q: %v", spec, err)
+		}
+		values[i] = value
+	}
+	if values[2] <= 0 || values[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("rectangle %q: empty", spec)
+	}
+
+	return image.Rect(values[0], values[1], values[0]+values[2], values[1]+values[3]), nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func init() {
+	commands["faults"] = faults
+}
+
+// faultFlags collects repeated -fault flags.
+type faultFlags []string
+
+func (f *faultFlags) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *faultFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// faults runs a test set against stuck-at faults and prints the undetected
+// ones and the coverage.
+func faults(args []string) error {
+	var specs faultFlags
+
+	flags := flag.NewFlagSet("faults", flag.ContinueOnError)
+	manifestFileName := flags.String("manifest", "", "pin manifest naming the test set's pin groups")
+	testsFileName := flags.String("tests", "", "test set JSON file")
+	verbose := flags.Bool("v", false, "print detected faults too")
+	flags.Var(&specs, "fault", "fault to check as x,y=0, gate:x,y=1 or pin group=0, repeatable; every net and wired-OR gate when omitted")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one image file")
+	}
+	if *testsFileName == "" {
+		return errors.New("no test set given")
+	}
+
+	data, err := ioutil.ReadFile(*testsFileName)
+	if err != nil {
+		return err
+	}
+	tests := new(gobls.TestSet)
+	err = json.Unmarshal(data, tests)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *testsFileName, err)
+	}
+
+	simulator, manifest, err := loadSimulator(flags.Arg(0), *manifestFileName)
+	if err != nil {
+		return err
+	}
+
+	// the devices are attached and reset for every run by FaultCoverage
+	list := simulator.FaultList()
+	if len(specs) > 0 {
+		list = list[:0]
+		for _, spec := range specs {
+			parsed, err := parseFault(manifest, spec)
+			if err != nil {
+				return err
+			}
+			list = append(list, parsed...)
+		}
+	}
+
+	coverage, err := simulator.FaultCoverage(manifest, tests, list)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range coverage.Results {
+		if !result.Detected {
+			fmt.Printf("%v: undetected\n", result.Fault)
+		} else if *verbose {
+			fmt.Printf("%v: detected by vector %d\n", result.Fault, result.Vector)
+		}
+	}
+	fmt.Printf("coverage %d/%d (%.1f%%)\n", coverage.Detected, len(coverage.Results), coverage.Ratio()*100)
+
+	return nil
+}
+
+// parseFault parses x,y=value, gate:x,y=value or name=value, the last giving
+// a fault per pin of the named group.
+func parseFault(manifest *gobls.Manifest, spec string) ([]gobls.Fault, error) {
+	i := strings.LastIndex(spec, "=")
+	if i < 0 {
+		return nil, fmt.Errorf("fault %q: missing =0 or =1", spec)
+	}
+
+	target, value := spec[:i], spec[i+1:]
+	if value != "0" && value != "1" {
+		return nil, fmt.Errorf("fault %q: value must be 0 or 1", spec)
+	}
+
+	fault := gobls.Fault{Value: value == "1"}
+	if strings.HasPrefix(target, "gate:") {
+		fault.Gate = true
+		target = strings.TrimPrefix(target, "gate:")
+	}
+
+	if coords := strings.Split(target, ","); len(coords) == 2 {
+		x, errX := strconv.Atoi(coords[0])
+		y, errY := strconv.Atoi(coords[1])
+		if errX == nil && errY == nil {
+			fault.X, fault.Y = x, y
+			return []gobls.Fault{fault}, nil
+		}
+	}
+
+	if fault.Gate {
+		return nil, fmt.Errorf("fault %q: gate faults need coordinates", spec)
+	}
+
+	bus, err := manifest.Bus(target)
+	if err != nil {
+		return nil, fmt.Errorf("fault %q: %v", spec, err)
+	}
+
+	list := make([]gobls.Fault, 0, len(bus))
+	for _, pin := range bus {
+		fault.X, fault.Y = pin.X, pin.Y
+		list = append(list, fault)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func init() {
+	commands["flatten"] = flatten
+}
+
+// flatten draws a layout into one image and writes the pins of its
+// instances as a manifest.
+func flatten(args []string) error {
+	flags := flag.NewFlagSet("flatten", flag.ContinueOnError)
+	outFileName := flags.String("o", "flat.png", "output image")
+	manifestFileName := flags.String("manifest", "", "output pin manifest, none when empty")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one layout file")
+	}
+
+	layout, err := gobls.LoadLayout(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	design, err := layout.Flatten()
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range design.Instances {
+		fmt.Printf("%s\t%s\t%d,%d %dx%d\n", instance.Path, instance.Module,
+			instance.Bounds.Min.X, instance.Bounds.Min.Y, instance.Bounds.Dx(), instance.Bounds.Dy())
+	}
+
+	err = savePNG(*outFileName, design.Image)
+	if err != nil {
+		return err
+	}
+
+	if *manifestFileName == "" {
+		return nil
+	}
+
+	file, err := os.Create(*manifestFileName)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "\t")
+	err = encoder.Encode(struct{ Pins map[string]gobls.Bus }{design.Manifest.Pins})
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
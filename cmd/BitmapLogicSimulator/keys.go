@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+// keyBinding drives input pins from a keyboard key.
+type keyBinding struct {
+	key    glfw.Key
+	pins   gobls.Bus
+	toggle bool
+	label  string
+}
+
+var keyBindings []keyBinding
+
+var keyNames = map[string]glfw.Key{
+	"SPACE": glfw.KeySpace, "ENTER": glfw.KeyEnter, "TAB": glfw.KeyTab,
+	"BACKSPACE": glfw.KeyBackspace, "INSERT": glfw.KeyInsert, "DELETE": glfw.KeyDelete,
+	"RIGHT": glfw.KeyRight, "LEFT": glfw.KeyLeft, "DOWN": glfw.KeyDown, "UP": glfw.KeyUp,
+	"PAGEUP": glfw.KeyPageUp, "PAGEDOWN": glfw.KeyPageDown, "HOME": glfw.KeyHome, "END": glfw.KeyEnd,
+	",": glfw.KeyComma, "-": glfw.KeyMinus, ".": glfw.KeyPeriod, "/": glfw.KeySlash,
+	";": glfw.KeySemicolon, "=": glfw.KeyEqual, "[": glfw.KeyLeftBracket, "]": glfw.KeyRightBracket,
+	"'": glfw.KeyApostrophe, "\\": glfw.KeyBackslash, "`": glfw.KeyGraveAccent,
+}
+
+// reservedKeys are the viewer keys of keyCallback. Bindings are handled
+// first, so a binding on one of them would hide its action.
+var reservedKeys = map[glfw.Key]string{
+	glfw.KeyE: "edit mode",
+	glfw.KeyC: "the critical path",
+	glfw.KeyH: "the heatmap",
+	glfw.KeyP: "pausing",
+	glfw.KeyN: "single steps",
+	glfw.KeyI: "the fan-in cone",
+	glfw.KeyO: "the fan-out cone",
+	glfw.KeyW: "the gates keeping a state",
+}
+
+func init() {
+	for i := 0; i < 26; i++ {
+		keyNames[string('A'+rune(i))] = glfw.KeyA + glfw.Key(i)
+	}
+	for i := 0; i < 10; i++ {
+		keyNames[string('0'+rune(i))] = glfw.Key0 + glfw.Key(i)
+	}
+	for i := 0; i < 12; i++ {
+		keyNames[fmt.Sprintf("F%d", i+1)] = glfw.KeyF1 + glfw.Key(i)
+	}
+}
+
+// loadKeyBindings resolves the configured bindings against the manifest.
+func loadKeyBindings(configs []KeyBindingConfig, manifest *gobls.Manifest) ([]keyBinding, error) {
+	bindings := make([]keyBinding, 0, len(configs))
+
+	for _, config := range configs {
+		key, ok := keyNames[strings.ToUpper(config.Key)]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", config.Key)
+		}
+		if action, ok := reservedKeys[key]; ok {
+			return nil, fmt.Errorf("key %q is used by the viewer for %s", config.Key, action)
+		}
+
+		binding := keyBinding{key: key, toggle: config.Toggle}
+
+		if config.Pins != "" {
+			pins, err := manifest.Bus(config.Pins)
+			if err != nil {
+				return nil, err
+			}
+			binding.pins = pins
+			binding.label = config.Pins
+		} else {
+			binding.pins = gobls.Bus{{X: config.X, Y: config.Y}}
+			binding.label = fmt.Sprintf("%d,%d", config.X, config.Y)
+		}
+
+		bindings = append(bindings, binding)
+	}
+
+	return bindings, nil
+}
+
+// handleKeyBinding drives the pins bound to a key and reports whether the
+// key was bound.
+func handleKeyBinding(key glfw.Key, action glfw.Action) bool {
+	handled := false
+
+	for _, binding := range keyBindings {
+		if binding.key != key || len(binding.pins) == 0 {
+			continue
+		}
+		handled = true
+
+		var state bool
+		if binding.toggle {
+			if action != glfw.Press {
+				continue
+			}
+			state = !simulator.Get(binding.pins[0].X, binding.pins[0].Y)
+		} else {
+			if action == glfw.Repeat {
+				continue
+			}
+			state = action == glfw.Press
+		}
+
+		for _, pin := range binding.pins {
+			simulator.Set(pin.X, pin.Y, state)
+		}
+	}
+
+	return handled
+}
+
+func keyBindingLegend(configs []KeyBindingConfig, bindings []keyBinding) []string {
+	lines := []string{"keys"}
+
+	for i, binding := range bindings {
+		mode := "hold"
+		if binding.toggle {
+			mode = "toggle"
+		}
+		lines = append(lines, fmt.Sprintf("%-6s %s (%s)", strings.ToUpper(configs[i].Key), binding.label, mode))
+	}
+
+	return lines
+}
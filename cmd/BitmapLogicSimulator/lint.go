@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func init() {
+	commands["lint"] = lint
+}
+
+// lint prints suspicious patterns of an image or layout, one per line.
+func lint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	manifestFileName := flags.String("manifest", "", "pin manifest, its pins count as driven and read")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one image file")
+	}
+
+	simulator, manifest, design, err := loadDesignSimulator(flags.Arg(0), *manifestFileName)
+	if err != nil {
+		return err
+	}
+	defer simulator.DetachAll()
+
+	issues := simulator.Lint(manifestPins(manifest))
+	for _, issue := range issues {
+		// name the module instance for layouts
+		if design != nil {
+			if path, local, ok := design.Locate(issue.X, issue.Y); ok {
+				fmt.Printf("%v (%s at %d,%d)\n", issue, path, local.X, local.Y)
+				continue
+			}
+		}
+
+		fmt.Println(issue)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issues", len(issues))
+	}
+
+	return nil
+}
+
+// manifestPins collects the pins of every group of a manifest.
+func manifestPins(manifest *gobls.Manifest) gobls.Bus {
+	pins := make(gobls.Bus, 0)
+	for _, bus := range manifest.Pins {
+		pins = append(pins, bus...)
+	}
+
+	return pins
+}
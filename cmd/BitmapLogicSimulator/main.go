@@ -42,6 +42,7 @@ const (
 
 	uniform sampler2D tex;
 	uniform sampler2D tex2;
+	uniform float overlayMix;
 
 	in vec2 fragTexCoord;
 
@@ -51,7 +52,7 @@ const (
 		vec4 back = texture(tex, fragTexCoord);
 		vec4 overlay = back * texture(tex2, fragTexCoord);
 
-		color = mix(back, overlay, 0.7);
+		color = mix(back, overlay, overlayMix);
 	}
 	`
 )
@@ -60,19 +61,29 @@ const (
 	WINDOW_WIDTH  = 800
 	WINDOW_HEIGHT = 600
 	WINDOW_TITLE  = "go-BitmapLogicSimulator by rlj1202"
+
+	OVERLAY_MIX = 0.7
 )
 
+var config *Config
+
 var watcher *fsnotify.Watcher
 
 var simulator *gobls.Simulator
+var manifest *gobls.Manifest
 
 var programId uint32
 
 var texId uint32
 
+var watchPoints []watchPoint
+var paused bool // stopped by a breakpoint or the P key
+
 var overlayPBO uint32
 var overlayTex uint32
 
+var showHeatmap bool // overlay shows toggle counts instead of net states
+
 var cameraZoom float32 = 1.0
 var cameraX float32
 var cameraY float32
@@ -86,6 +97,10 @@ var mouseXIdx int
 var mouseYIdx int
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	c, err := loadConfig("config.json")
 	defer saveConfig("config.json", c)
 	if err != nil {
@@ -93,6 +108,7 @@ func main() {
 	}
 
 	log.Printf("config : %v\n", *c)
+	config = c
 
 	watcher, err := fsnotify.NewWatcher()
 	defer watcher.Close()
@@ -161,6 +177,7 @@ func main() {
 	gl.Uniform1i(texLoc, 0)
 	tex2Loc := gl.GetUniformLocation(programId, gl.Str("tex2\x00"))
 	gl.Uniform1i(tex2Loc, 1)
+	setOverlayMix(OVERLAY_MIX)
 
 	log.Println("create vao")
 
@@ -204,6 +221,7 @@ func main() {
 
 	// create simulation, overlay PBO, overlay texture
 	simulator = gobls.NewSimulator()
+	simulator.DumpImages = true
 
 	log.Println("process image")
 
@@ -213,6 +231,41 @@ func main() {
 		panic(err)
 	}
 
+	// attach devices
+	manifest = gobls.NewManifest()
+	if c.ManifestFileName != "" {
+		manifest, err = gobls.LoadManifest(c.ManifestFileName)
+		if err != nil {
+			panic(err)
+		}
+
+		err = manifest.Attach(simulator)
+		if err != nil {
+			panic(err)
+		}
+		defer simulator.DetachAll()
+
+		for _, device := range simulator.Devices() {
+			if display, ok := device.(*gobls.Display); ok {
+				addPanel(display.Image())
+			}
+		}
+	}
+
+	watchPoints, err = loadWatchPoints(simulator, manifest, c.Breakpoints, c.Watches)
+	if err != nil {
+		panic(err)
+	}
+
+	// bind keys
+	keyBindings, err = loadKeyBindings(c.KeyBindings, manifest)
+	if err != nil {
+		panic(err)
+	}
+	if len(keyBindings) > 0 {
+		addPanel(textImage(keyBindingLegend(c.KeyBindings, keyBindings)))
+	}
+
 	// start simulation
 	width, height := window.GetSize()
 	updateProjectionMat(programId, float32(width), float32(height)/float32(width))
@@ -250,9 +303,13 @@ func main() {
 		gl.BindTexture(gl.TEXTURE_2D, overlayTex)
 		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 6)
 
+		// render device panels
+		drawPanels(window)
+		drawTooltip(window)
+
 		// simulate
-		for i := 0; i < c.SimulationsPerFrame; i++ {
-			simulator.Simulate()
+		for i := 0; i < c.SimulationsPerFrame && !paused; i++ {
+			simulateStep(window)
 		}
 
 		// display
@@ -261,16 +318,11 @@ func main() {
 }
 
 func loadImage(imgFileName string) error {
-	imgFile, err := os.Open(imgFileName)
-	defer imgFile.Close()
-	if err != nil {
-		return err
-	}
-
-	img, _, err := image.Decode(imgFile)
+	img, err := decodeImage(imgFileName)
 	if err != nil {
 		return err
 	}
+	img = openDocument(imgFileName, img)
 
 	if texId == 0 {
 		gl.GenTextures(1, &texId)
@@ -282,7 +334,21 @@ func loadImage(imgFileName string) error {
 		return err
 	}
 
-	simulator.LoadImage(img)
+	// only the changed regions are extracted again when the size is kept
+	regions := simulator.UpdateImage(img)
+	log.Printf("extracted regions : %v\n", regions)
+
+	if config.ColorSources {
+		attachColorSources(simulator, img, config.ClockPeriod)
+	}
+
+	if hasHighlight("path") {
+		showCriticalPath()
+	}
+	updateForceHighlights()
+	updateSelection()
+	updateCone()
+	invalidateTooltip()
 
 	width, height := simulator.Size()
 
@@ -315,17 +381,30 @@ func loadImage(imgFileName string) error {
 
 func updateScaleMat(x, y, zoom float32) {
 	log.Printf("update scale mat : x = %f, y = %f, zoom = %f\n", x, y, zoom)
+	setScaleMat(x*zoom, y*zoom)
+}
+
+func updateCameraLocMat() {
+	setCameraLocMat(cameraX, -cameraY)
+}
+
+func setScaleMat(x, y float32) {
 	scaleLoc := gl.GetUniformLocation(programId, gl.Str("scale\x00"))
-	scaleMat := mgl32.Scale3D(x*zoom, y*zoom, 1)
+	scaleMat := mgl32.Scale3D(x, y, 1)
 	gl.UniformMatrix4fv(scaleLoc, 1, false, &scaleMat[0])
 }
 
-func updateCameraLocMat() {
+func setCameraLocMat(x, y float32) {
 	cameraLocLoc := gl.GetUniformLocation(programId, gl.Str("cameraLoc\x00"))
-	cameraLocMat := mgl32.Translate3D(cameraX, -cameraY, 0)
+	cameraLocMat := mgl32.Translate3D(x, y, 0)
 	gl.UniformMatrix4fv(cameraLocLoc, 1, false, &cameraLocMat[0])
 }
 
+func setOverlayMix(mix float32) {
+	overlayMixLoc := gl.GetUniformLocation(programId, gl.Str("overlayMix\x00"))
+	gl.Uniform1f(overlayMixLoc, mix)
+}
+
 func updateOverlayTex() {
 	width, height := simulator.Size()
 
@@ -335,19 +414,32 @@ func updateOverlayTex() {
 	if overlayPBOPtr != nil {
 		overlayPBOSlice := (*[1 << 30]byte)(overlayPBOPtr)[: width*height*4 : width*height*4]
 
-		simulator.PerPixel(func(x, y int, state bool) {
-			index := x + y*width
-			var value byte
-			if state {
-				value = 255
-			} else {
-				value = 0
-			}
-			overlayPBOSlice[index*4] = value
-			overlayPBOSlice[index*4+1] = value
-			overlayPBOSlice[index*4+2] = value
-			overlayPBOSlice[index*4+3] = 255
-		})
+		if showHeatmap {
+			max := simulator.MaxToggles()
+			simulator.PerPixelToggles(func(x, y, toggles int) {
+				index := x + y*width
+				c := gobls.HeatColor(toggles, max)
+				overlayPBOSlice[index*4] = c.R
+				overlayPBOSlice[index*4+1] = c.G
+				overlayPBOSlice[index*4+2] = c.B
+				overlayPBOSlice[index*4+3] = 255
+			})
+		} else {
+			simulator.PerPixel(func(x, y int, state bool) {
+				index := x + y*width
+				var value byte
+				if state {
+					value = 255
+				} else {
+					value = 0
+				}
+				overlayPBOSlice[index*4] = value
+				overlayPBOSlice[index*4+1] = value
+				overlayPBOSlice[index*4+2] = value
+				overlayPBOSlice[index*4+3] = 255
+			})
+		}
+		paintHighlights(overlayPBOSlice, width, height)
 
 		success := gl.UnmapBuffer(gl.PIXEL_UNPACK_BUFFER)
 		if !success {
@@ -419,6 +511,75 @@ func loadShader(rawSource string, shaderType uint32) (uint32, error) {
 	return shader, nil
 }
 
+// toggleHeatmap switches the overlay between net states and toggle counts.
+// Counting starts over whenever the heatmap is shown.
+func toggleHeatmap() {
+	showHeatmap = !showHeatmap
+	simulator.TrackActivity(showHeatmap)
+
+	if !showHeatmap {
+		log.Printf("heatmap : %d idle nets\n", len(simulator.IdleNets()))
+	}
+}
+
+// toggleForce holds a net at the opposite of its current value, or releases
+// it when already forced.
+func toggleForce(x, y int) {
+	if simulator.IsForced(x, y) {
+		simulator.Release(x, y)
+	} else {
+		simulator.Force(x, y, !simulator.Get(x, y))
+	}
+
+	updateForceHighlights()
+}
+
+func updateForceHighlights() {
+	high := make([]gobls.Pin, 0)
+	low := make([]gobls.Pin, 0)
+	for pin, value := range simulator.ForcedPixels() {
+		if value {
+			high = append(high, pin)
+		} else {
+			low = append(low, pin)
+		}
+	}
+
+	setHighlight("force-high", high, FORCE_HIGH_COLOR)
+	setHighlight("force-low", low, FORCE_LOW_COLOR)
+}
+
+// simulateStep simulates one step and pauses when a breakpoint fires.
+func simulateStep(w *glfw.Window) {
+	simulator.Simulate()
+
+	if len(watchPoints) > 0 && checkWatchPoints(simulator, watchPoints, config.SnapshotPrefix) {
+		paused = true
+		updateTitle(w)
+	}
+}
+
+func toggleCriticalPath() {
+	if hasHighlight("path") {
+		clearHighlight("path")
+		return
+	}
+
+	showCriticalPath()
+}
+
+func showCriticalPath() {
+	path, err := findCriticalPath(simulator, manifest, config.PathInputs, config.PathOutputs)
+	if err != nil {
+		log.Printf("critical path : %v\n", err)
+		clearHighlight("path")
+		return
+	}
+
+	log.Printf("critical path : %d gates, at most %d steps\n", len(path.Gates), path.MaxSteps)
+	setHighlight("path", gatePixels(path.Gates), PATH_COLOR)
+}
+
 func sizeCallback(w *glfw.Window, width, height int) {
 	gl.Viewport(0, 0, int32(width), int32(height))
 	updateProjectionMat(programId, float32(width), float32(height)/float32(width))
@@ -448,10 +609,17 @@ func cursorToImagePoint(w *glfw.Window, x, y float64) (int, int) {
 }
 
 func mouseButtonCallback(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mod glfw.ModifierKey) {
+	// clicks and keys set nets even while paused
+	invalidateTooltip()
+
 	x, y := w.GetCursorPos()
 	xIdx, yIdx := cursorToImagePoint(w, x, y)
 	simWidth, simHeight := simulator.Size()
 
+	if editMode && handleEditMouse(w, button, action, mod, xIdx, yIdx) {
+		return
+	}
+
 	if button == glfw.MouseButtonMiddle {
 		switch action {
 		case glfw.Press:
@@ -462,7 +630,11 @@ func mouseButtonCallback(w *glfw.Window, button glfw.MouseButton, action glfw.Ac
 		}
 	} else if button == glfw.MouseButtonLeft {
 		if 0 <= xIdx && xIdx < simWidth && 0 <= yIdx && yIdx < simHeight {
-			if action == glfw.Press {
+			if action == glfw.Press && mod&glfw.ModShift != 0 {
+				toggleForce(xIdx, yIdx)
+			} else if action == glfw.Press && mod&glfw.ModControl != 0 {
+				toggleSelection(xIdx, yIdx)
+			} else if action == glfw.Press {
 				mouseInteracting = true
 				mouseXIdx = xIdx
 				mouseYIdx = yIdx
@@ -503,9 +675,51 @@ func cursorPosCallback(w *glfw.Window, xpos, ypos float64) {
 
 		updateCameraLocMat()
 	}
+
+	if editMode {
+		editCursorMoved(cursorToImagePoint(w, xpos, ypos))
+	}
 }
 
 func keyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	invalidateTooltip()
+
+	if editMode && action == glfw.Press && handleEditKey(w, key, mods) {
+		return
+	}
+
+	if handleKeyBinding(key, action) {
+		return
+	}
+
+	if key == glfw.KeyE && action == glfw.Press {
+		toggleEditMode(w)
+	}
+
+	if key == glfw.KeyC && action == glfw.Press {
+		toggleCriticalPath()
+	}
+
+	if key == glfw.KeyH && action == glfw.Press {
+		toggleHeatmap()
+	}
+
+	if key == glfw.KeyP && action == glfw.Press {
+		paused = !paused
+		updateTitle(w)
+	}
+
+	if key == glfw.KeyN && action != glfw.Release && paused {
+		simulateStep(w)
+		updateTitle(w)
+	}
+
+	if (key == glfw.KeyI || key == glfw.KeyO || key == glfw.KeyW) && action == glfw.Press {
+		cursorX, cursorY := w.GetCursorPos()
+		x, y := cursorToImagePoint(w, cursorX, cursorY)
+		toggleCone(key, x, y)
+	}
+
 	if glfw.KeyKP0 <= key && key <= glfw.KeyKP9 && action == glfw.Press {
 		width, height := simulator.Size()
 
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+var (
+	PATH_COLOR       = color.RGBA{255, 60, 60, 255}
+	FORCE_HIGH_COLOR = color.RGBA{255, 170, 0, 255}
+	FORCE_LOW_COLOR  = color.RGBA{130, 70, 0, 255}
+	NET_COLOR        = color.RGBA{0, 200, 255, 255}
+	DRIVER_COLOR     = color.RGBA{60, 255, 60, 255}
+	READER_COLOR     = color.RGBA{200, 80, 255, 255}
+	CONE_COLOR       = color.RGBA{255, 230, 0, 255}
+)
+
+// highlight colors pixels of the overlay on top of the net states.
+type highlight struct {
+	name   string
+	pixels []gobls.Pin
+	c      color.RGBA
+}
+
+var highlights []*highlight
+
+func setHighlight(name string, pixels []gobls.Pin, c color.RGBA) {
+	for _, h := range highlights {
+		if h.name == name {
+			h.pixels = pixels
+			h.c = c
+			return
+		}
+	}
+
+	highlights = append(highlights, &highlight{name, pixels, c})
+}
+
+func clearHighlight(name string) {
+	for i, h := range highlights {
+		if h.name == name {
+			highlights = append(highlights[:i], highlights[i+1:]...)
+			return
+		}
+	}
+}
+
+func hasHighlight(name string) bool {
+	for _, h := range highlights {
+		if h.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// paintHighlights writes the highlights into an RGBA overlay buffer.
+func paintHighlights(pix []byte, width, height int) {
+	for _, h := range highlights {
+		for _, p := range h.pixels {
+			if p.X < 0 || p.Y < 0 || p.X >= width || p.Y >= height {
+				continue
+			}
+
+			index := (p.X + p.Y*width) * 4
+			pix[index] = h.c.R
+			pix[index+1] = h.c.G
+			pix[index+2] = h.c.B
+			pix[index+3] = 255
+		}
+	}
+}
+
+// gatePixels returns the 3x3 blocks around gate centers.
+func gatePixels(centers []gobls.Pin) []gobls.Pin {
+	pixels := make([]gobls.Pin, 0, len(centers)*9)
+
+	for _, center := range centers {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				pixels = append(pixels, gobls.Pin{X: center.X + dx, Y: center.Y + dy})
+			}
+		}
+	}
+
+	return pixels
+}
+
+// saveHighlightImage writes the image dimmed to a third with pixels
+// painted over it.
+func saveHighlightImage(fileName string, img image.Image, pixels []gobls.Pin, c color.RGBA) error {
+	out := dimmedImage(img)
+	for _, p := range pixels {
+		out.SetRGBA(p.X, p.Y, c)
+	}
+
+	return savePNG(fileName, out)
+}
+
+// dimmedImage copies an image with its colors dimmed to a third.
+func dimmedImage(img image.Image) *image.RGBA {
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Rect, img, out.Rect.Min, draw.Src)
+
+	for i := 0; i < len(out.Pix); i += 4 {
+		out.Pix[i] /= 3
+		out.Pix[i+1] /= 3
+		out.Pix[i+2] /= 3
+	}
+
+	return out
+}
+
+func savePNG(fileName string, img image.Image) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	err = png.Encode(file, img)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// manifestBus concatenates comma separated pin groups of a manifest.
+func manifestBus(manifest *gobls.Manifest, names string) (gobls.Bus, error) {
+	bus := make(gobls.Bus, 0)
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		pins, err := manifest.Bus(name)
+		if err != nil {
+			return nil, err
+		}
+		bus = append(bus, pins...)
+	}
+
+	return bus, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"image"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+const (
+	PANEL_MARGIN = 10
+)
+
+// panel is an image drawn at a fixed place on the screen, on top of the
+// bitmap. Its texture is refreshed from the image every frame.
+type panel struct {
+	img   *image.RGBA
+	texId uint32
+}
+
+var panels []*panel
+
+func addPanel(img *image.RGBA) {
+	p := &panel{img: img}
+
+	gl.GenTextures(1, &p.texId)
+	gl.BindTexture(gl.TEXTURE_2D, p.texId)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	panels = append(panels, p)
+}
+
+// drawPanels stacks the panels along the right edge of the window.
+func drawPanels(w *glfw.Window) {
+	if len(panels) == 0 {
+		return
+	}
+
+	screenWidth, screenHeight := w.GetSize()
+	top := float32(screenHeight)/2 - PANEL_MARGIN
+	right := float32(screenWidth)/2 - PANEL_MARGIN
+
+	setOverlayMix(0)
+	for _, p := range panels {
+		width := p.img.Rect.Dx()
+		height := p.img.Rect.Dy()
+
+		// integer zoom so the panel takes about a third of the window height
+		zoom := screenHeight / 3 / height
+		if zoom < 1 {
+			zoom = 1
+		}
+		panelWidth := float32(width * zoom)
+		panelHeight := float32(height * zoom)
+
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, p.texId)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(p.img.Pix))
+
+		setScaleMat(panelWidth, panelHeight)
+		setCameraLocMat((right-panelWidth/2)/panelWidth, (top-panelHeight/2)/panelHeight)
+		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 6)
+
+		top -= panelHeight + PANEL_MARGIN
+	}
+	setOverlayMix(OVERLAY_MIX)
+
+	// restore the bitmap's transform
+	simWidth, simHeight := simulator.Size()
+	setScaleMat(float32(simWidth)*cameraZoom, float32(simHeight)*cameraZoom)
+	updateCameraLocMat()
+}
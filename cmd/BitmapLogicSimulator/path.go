@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func init() {
+	commands["path"] = criticalPath
+}
+
+// criticalPath prints the longest gate chain between two groups of pins.
+func criticalPath(args []string) error {
+	flags := flag.NewFlagSet("path", flag.ContinueOnError)
+	manifestFileName := flags.String("manifest", "", "pin manifest")
+	inputs := flags.String("in", "", "comma separated input pin groups")
+	outputs := flags.String("out", "", "comma separated output pin groups")
+	outFileName := flags.String("o", "", "write an image highlighting the path")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one image file")
+	}
+
+	simulator, manifest, err := loadSimulator(flags.Arg(0), *manifestFileName)
+	if err != nil {
+		return err
+	}
+	defer simulator.DetachAll()
+
+	path, err := findCriticalPath(simulator, manifest, *inputs, *outputs)
+	if err != nil {
+		return err
+	}
+
+	for _, gate := range path.Gates {
+		fmt.Printf("%d,%d\n", gate.X, gate.Y)
+	}
+	fmt.Printf("%d gates, at most %d steps\n", len(path.Gates), path.MaxSteps)
+
+	if *outFileName != "" {
+		return saveHighlightImage(*outFileName, simulator.Image(), gatePixels(path.Gates), PATH_COLOR)
+	}
+
+	return nil
+}
+
+func findCriticalPath(simulator *gobls.Simulator, manifest *gobls.Manifest, inputs, outputs string) (*gobls.Path, error) {
+	inputBus, err := manifestBus(manifest, inputs)
+	if err != nil {
+		return nil, err
+	}
+	outputBus, err := manifestBus(manifest, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return simulator.CriticalPath(inputBus, outputBus)
+}
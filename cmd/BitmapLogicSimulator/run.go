@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func init() {
+	commands["run"] = runHeadless
+}
+
+// runHeadless simulates a circuit with its devices and no window, e.g.
+//
+//	echo hello | BitmapLogicSimulator run -manifest cpu.json -steps 100000 cpu.png
+func runHeadless(args []string) error {
+	var breakpoints, watches exprFlags
+
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	manifestFileName := flags.String("manifest", "", "pin manifest with devices")
+	steps := flags.Int("steps", 1000, "number of simulation steps")
+	useColorSources := flags.Bool("color-sources", false, "drive pixels painted with the reserved source colors")
+	clockPeriod := flags.Int("clock-period", gobls.DEFAULT_CLOCK_PERIOD, "period of color clocks in steps")
+	heatmapFileName := flags.String("heatmap", "", "write the toggle counts of the nets as an image")
+	flags.Var(&breakpoints, "break", "stop when a condition over pin groups becomes true, e.g. \"clk rises && we\", repeatable")
+	flags.Var(&watches, "watch", "log when a condition becomes true and go on, repeatable")
+	snapshotPrefix := flags.String("snapshot", "", "write the net states as <prefix><step>.png when a condition fires")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one image file")
+	}
+
+	simulator, manifest, err := loadSimulator(flags.Arg(0), *manifestFileName)
+	if err != nil {
+		return err
+	}
+	err = attachDevices(simulator, manifest)
+	if err != nil {
+		return err
+	}
+
+	if *useColorSources {
+		attachColorSources(simulator, simulator.Image(), *clockPeriod)
+	}
+
+	if *heatmapFileName != "" {
+		simulator.TrackActivity(true)
+	}
+
+	points, err := loadWatchPoints(simulator, manifest, breakpoints, watches)
+	if err != nil {
+		simulator.DetachAll()
+		return err
+	}
+
+	start := time.Now()
+	for i := 0; i < *steps; i++ {
+		simulator.Simulate()
+
+		if len(points) > 0 && checkWatchPoints(simulator, points, *snapshotPrefix) {
+			break
+		}
+	}
+	log.Printf("%d steps in %v\n", simulator.Steps(), time.Since(start))
+
+	if *heatmapFileName != "" {
+		for _, pin := range simulator.IdleNets() {
+			log.Printf("net at %d,%d never toggled\n", pin.X, pin.Y)
+		}
+
+		err = savePNG(*heatmapFileName, simulator.Heatmap())
+		if err != nil {
+			simulator.DetachAll()
+			return err
+		}
+	}
+
+	// closing devices also saves display outputs
+	return simulator.DetachAll()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+var selected bool // a net is highlighted
+var selectedX, selectedY int
+
+// toggleSelection highlights the net under a pixel with the gates driving
+// and reading it. Clicking the selected net or insulation clears it.
+func toggleSelection(x, y int) {
+	if net, ok := simulator.NetAt(x, y); ok && selected {
+		if old, _ := simulator.NetAt(selectedX, selectedY); net.ID == old.ID {
+			clearSelection()
+			return
+		}
+	}
+
+	selected = true
+	selectedX, selectedY = x, y
+	updateSelection()
+
+	if net, ok := simulator.NetAt(x, y); ok {
+		log.Printf("net %d : %d px, %d drivers, %d readers\n", net.ID, net.Pixels, len(net.Drivers), len(net.Readers))
+	}
+}
+
+func clearSelection() {
+	selected = false
+	clearHighlight("net-drivers")
+	clearHighlight("net-readers")
+	clearHighlight("net")
+}
+
+// updateSelection highlights the selected net again after the image
+// changed, clearing it when the pixel does not conduct anymore.
+func updateSelection() {
+	if !selected {
+		return
+	}
+
+	mask := simulator.NetMask(selectedX, selectedY)
+	if mask == nil {
+		clearSelection()
+		return
+	}
+	net, _ := simulator.NetAt(selectedX, selectedY)
+
+	pixels := make([]gobls.Pin, 0, net.Pixels)
+	for y := mask.Rect.Min.Y; y < mask.Rect.Max.Y; y++ {
+		for x := mask.Rect.Min.X; x < mask.Rect.Max.X; x++ {
+			if mask.AlphaAt(x, y).A != 0 {
+				pixels = append(pixels, gobls.Pin{X: x, Y: y})
+			}
+		}
+	}
+
+	// the net is painted last, over the input and output pixels of its gates
+	setHighlight("net-drivers", gatePixels(net.Drivers), DRIVER_COLOR)
+	setHighlight("net-readers", gatePixels(net.Readers), READER_COLOR)
+	setHighlight("net", pixels, NET_COLOR)
+}
+
+var coneKey glfw.Key // key of the shown cone, 0 for none
+var coneX, coneY int
+
+// toggleCone highlights the fan-in cone of the net under a pixel for I, the
+// fan-out cone for O and the gates keeping its state for W, logging the
+// sources of the state. The same key again clears it.
+func toggleCone(key glfw.Key, x, y int) {
+	if coneKey == key {
+		coneKey = 0
+		clearHighlight("cone")
+		return
+	}
+
+	coneKey = key
+	coneX, coneY = x, y
+	updateCone()
+}
+
+// updateCone finds the shown cone again after the image changed.
+func updateCone() {
+	if coneKey == 0 {
+		return
+	}
+
+	var c *gobls.Cone
+	ok := false
+	switch coneKey {
+	case glfw.KeyI:
+		c, ok = simulator.FanIn(coneX, coneY, config.ConeLevels)
+	case glfw.KeyO:
+		c, ok = simulator.FanOut(coneX, coneY, config.ConeLevels)
+	case glfw.KeyW:
+		var t *gobls.Trace
+		t, ok = simulator.Trace(coneX, coneY)
+		if ok {
+			for _, source := range t.Sources {
+				log.Printf("%s from %d,%d%s\n", stateName(source.State), source.Pin.X, source.Pin.Y, heldName(source.Held))
+			}
+			setHighlight("cone", traceGatePixels(t), CONE_COLOR)
+		}
+	}
+
+	if !ok {
+		coneKey = 0
+		clearHighlight("cone")
+		return
+	}
+	if c != nil {
+		log.Printf("cone : %d gates, %d nets\n", len(c.Gates), len(c.Nets))
+		setHighlight("cone", conePixels(simulator, c), CONE_COLOR)
+	}
+}
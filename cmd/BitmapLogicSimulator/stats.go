@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+)
+
+func init() {
+	commands["stats"] = stats
+}
+
+// stats prints a summary of an image's circuit.
+func stats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print as JSON")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expected one image file")
+	}
+
+	simulator, _, err := loadSimulator(flags.Arg(0), "")
+	if err != nil {
+		return err
+	}
+
+	s := simulator.Stats()
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(s)
+	}
+
+	s.Write(os.Stdout)
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+const (
+	GLYPH_WIDTH  = 3
+	GLYPH_HEIGHT = 5
+	TEXT_SCALE   = 2
+	TEXT_PADDING = 4
+
+	TEXT_LINE_HEIGHT = (GLYPH_HEIGHT + 2) * TEXT_SCALE
+	TEXT_CHAR_WIDTH  = (GLYPH_WIDTH + 1) * TEXT_SCALE
+)
+
+var (
+	TEXT_COLOR      = color.RGBA{230, 230, 230, 255}
+	TEXT_BACKGROUND = color.RGBA{30, 30, 30, 255}
+)
+
+// 3x5 glyphs, lower case letters are drawn as upper case
+var glyphs = map[rune]string{
+	'A': ".#.#.#####.##.#", 'B': "##.#.###.#.###.", 'C': ".###..#..#...##",
+	'D': "##.#.##.##.###.", 'E': "####..##.#..###", 'F': "####..##.#..#..",
+	'G': ".###..#.##.#.##", 'H': "#.##.#####.##.#", 'I': "###.#..#..#.###",
+	'J': "..#..#..##.#.#.", 'K': "#.##.###.#.##.#", 'L': "#..#..#..#..###",
+	'M': "#.########.##.#", 'N': "##.#.##.##.##.#", 'O': ".#.#.##.##.#.#.",
+	'P': "##.#.###.#..#..", 'Q': ".#.#.##.###..##", 'R': "##.#.###.#.##.#",
+	'S': ".###...#...###.", 'T': "###.#..#..#..#.", 'U': "#.##.##.##.####",
+	'V': "#.##.##.#.#..#.", 'W': "#.##.########.#", 'X': "#.##.#.#.#.##.#",
+	'Y': "#.##.#.#..#..#.", 'Z': "###..#.#.#..###", '0': "####.##.##.####",
+	'1': ".#.##..#..#.###", '2': "##...#.#.#..###", '3': "##...#.#...###.",
+	'4': "#.##.####..#..#", '5': "####..##...###.", '6': ".###..####.####",
+	'7': "###..#.#..#..#.", '8': "####.#####.####", '9': "####.####..###.",
+	':': "....#.....#....", '.': ".............#.", ',': "..........#.#..",
+	'-': "......###......", '_': "............###", '=': "...###...###...",
+	'(': ".#.#..#..#...#.", ')': ".#...#..#..#.#.", '[': "##.#..#..#..##.",
+	']': ".##..#..#..#.##", '/': "..#..#.#.#..#..", '+': "....#.###.#....",
+	'>': "#...#...#.#.#..", '<': "..#.#.#...#...#", '!': ".#..#..#.....#.",
+	'?': "##...#.#.....#.", '\'': ".#..#..........", '"': "#.##.#.........",
+	'*': "#.#.#.#.#......", '&': ".#.#.#.#.#.#.##", '|': ".#..#..#..#..#.",
+	'#': "#.#####.#####.#", '%': "#....#.#.#....#", '^': ".#.#.#.........",
+}
+
+// textImage renders lines of text on an opaque background.
+func textImage(lines []string) *image.RGBA {
+	columns := 1
+	for _, line := range lines {
+		if len(line) > columns {
+			columns = len(line)
+		}
+	}
+
+	width := columns*TEXT_CHAR_WIDTH + 2*TEXT_PADDING
+	height := len(lines)*TEXT_LINE_HEIGHT + 2*TEXT_PADDING
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Rect, image.NewUniform(TEXT_BACKGROUND), image.ZP, draw.Src)
+
+	for i, line := range lines {
+		drawText(img, TEXT_PADDING, TEXT_PADDING+i*TEXT_LINE_HEIGHT, line, TEXT_COLOR)
+	}
+
+	return img
+}
+
+func drawText(img *image.RGBA, x, y int, text string, c color.RGBA) {
+	for i, r := range strings.ToUpper(text) {
+		glyph, ok := glyphs[r]
+		if !ok {
+			continue
+		}
+
+		for j, cell := range glyph {
+			if cell != '#' {
+				continue
+			}
+
+			gx := x + i*TEXT_CHAR_WIDTH + j%GLYPH_WIDTH*TEXT_SCALE
+			gy := y + j/GLYPH_WIDTH*TEXT_SCALE
+			draw.Draw(img, image.Rect(gx, gy, gx+TEXT_SCALE, gy+TEXT_SCALE), image.NewUniform(c), image.ZP, draw.Src)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+const (
+	TOOLTIP_OFFSET = 16 // distance from the cursor in screen pixels
+)
+
+var tooltip *panel
+
+// hovered pixel and step the tooltip texture shows, step -1 to describe again
+var tooltipX, tooltipY int
+var tooltipStep = -1
+var tooltipShown bool
+
+// invalidateTooltip describes the hovered pixel again after the image changed.
+func invalidateTooltip() {
+	tooltipStep = -1
+}
+
+// tooltipLines describes the gate and the net under a pixel.
+func tooltipLines(x, y int) []string {
+	lines := make([]string, 0)
+
+	if gate, ok := simulator.GateAt(x, y); ok {
+		kind := "not"
+		if gate.Buffer {
+			kind = "buffer"
+		}
+		lines = append(lines,
+			fmt.Sprintf("%s %s at %d,%d", kind, dirNames[gate.Dir], gate.X, gate.Y),
+			fmt.Sprintf("%s %.2f, in net %d, out net %d", stateName(gate.State), gate.SlowState, gate.InNet, gate.OutNet))
+	}
+
+	if net, ok := simulator.NetAt(x, y); ok {
+		lines = append(lines,
+			fmt.Sprintf("net %d %s", net.ID, stateName(net.State)),
+			fmt.Sprintf("%d px, %d,%d to %d,%d", net.Pixels, net.Bounds.Min.X, net.Bounds.Min.Y, net.Bounds.Max.X-1, net.Bounds.Max.Y-1),
+			fmt.Sprintf("%d drivers, %d readers", len(net.Drivers), len(net.Readers)))
+	}
+
+	return lines
+}
+
+func stateName(state bool) string {
+	if state {
+		return "high"
+	}
+	return "low"
+}
+
+// drawTooltip draws the description of what is under the cursor below and
+// right of it.
+func drawTooltip(w *glfw.Window) {
+	cursorX, cursorY := w.GetCursorPos()
+	x, y := cursorToImagePoint(w, cursorX, cursorY)
+
+	if tooltip == nil {
+		tooltip = new(panel)
+		gl.GenTextures(1, &tooltip.texId)
+		gl.BindTexture(gl.TEXTURE_2D, tooltip.texId)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+	}
+
+	// describe the pixel again only when it or the step changed
+	upload := false
+	if x != tooltipX || y != tooltipY || simulator.Steps() != tooltipStep {
+		tooltipX, tooltipY, tooltipStep = x, y, simulator.Steps()
+
+		lines := tooltipLines(x, y)
+		tooltipShown = len(lines) > 0
+		if tooltipShown {
+			tooltip.img = textImage(lines)
+			upload = true
+		}
+	}
+	if !tooltipShown {
+		return
+	}
+
+	screenWidth, screenHeight := w.GetSize()
+	width := float32(tooltip.img.Rect.Dx())
+	height := float32(tooltip.img.Rect.Dy())
+
+	// screen coordinates from the window center, y up
+	centerX := float32(cursorX) - float32(screenWidth)/2 + TOOLTIP_OFFSET + width/2
+	centerY := float32(screenHeight)/2 - float32(cursorY) - TOOLTIP_OFFSET - height/2
+
+	setOverlayMix(0)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, tooltip.texId)
+	if upload {
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(tooltip.img.Pix))
+	}
+
+	setScaleMat(width, height)
+	setCameraLocMat(centerX/width, centerY/height)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 6)
+	setOverlayMix(OVERLAY_MIX)
+
+	// restore the bitmap's transform
+	simWidth, simHeight := simulator.Size()
+	setScaleMat(float32(simWidth)*cameraZoom, float32(simHeight)*cameraZoom)
+	updateCameraLocMat()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+// exprFlags collects repeated condition flags.
+type exprFlags []string
+
+func (f *exprFlags) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *exprFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// watchPoint logs when its condition becomes true and stops the simulation
+// too when it is a breakpoint.
+type watchPoint struct {
+	watch *gobls.Watch
+	stop  bool
+}
+
+// loadWatchPoints parses breakpoints and watches and samples the current
+// state, so conditions already true do not fire.
+func loadWatchPoints(simulator *gobls.Simulator, manifest *gobls.Manifest, breakpoints, watches []string) ([]watchPoint, error) {
+	points := make([]watchPoint, 0, len(breakpoints)+len(watches))
+
+	for i, expr := range append(append([]string{}, breakpoints...), watches...) {
+		watch, err := gobls.NewWatch(expr, manifest)
+		if err != nil {
+			return nil, err
+		}
+		watch.Check(simulator)
+
+		points = append(points, watchPoint{watch, i < len(breakpoints)})
+	}
+
+	return points, nil
+}
+
+// checkWatchPoints checks every condition after a step, logs the ones that
+// fired and writes a snapshot of the net states when a prefix is given. It
+// reports whether a breakpoint fired.
+func checkWatchPoints(simulator *gobls.Simulator, points []watchPoint, snapshotPrefix string) bool {
+	stop := false
+	fired := false
+
+	for _, point := range points {
+		if !point.watch.Check(simulator) {
+			continue
+		}
+		fired = true
+
+		kind := "watch"
+		if point.stop {
+			kind = "break"
+			stop = true
+		}
+		log.Printf("step %d : %s %q, %s\n", simulator.Steps(), kind, point.watch.Expr, point.watch.Values())
+	}
+
+	if fired && snapshotPrefix != "" {
+		fileName := fmt.Sprintf("%s%d.png", snapshotPrefix, simulator.Steps())
+		err := savePNG(fileName, simulator.StateImage())
+		if err != nil {
+			log.Printf("snapshot : %v\n", err)
+		} else {
+			log.Printf("snapshot : %s\n", fileName)
+		}
+	}
+
+	return stop
+}
@@ -0,0 +1,299 @@
+package gobls
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	CODEGEN_GATES_PER_FUNC = 1024
+)
+
+// GenerateGo writes the loaded circuit as a Go source file of the given
+// package. The generated Circuit keeps net and gate states in packed bit
+// sets and evaluates every gate and buffer once per Step with straight-line
+// bit operations, in a fixed order where a gate comes after the gates
+// driving it, feedback loops aside. Gates switch immediately, there is no
+// rise and fall time, so combinational logic settles in a single Step.
+//
+// The generated API mirrors Simulator: Step (or Simulate), Set, Get and
+// Size, plus NetAt, GetNet and SetNet working on net indices.
+func (simulator *Simulator) GenerateGo(w io.Writer, packageName string) error {
+	// compact net indices
+	netIndex := make(map[int]int)
+	nets := make([]int, 0)
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.wireAt(x, y)
+			if _, ok := netIndex[wire]; wire >= 0 && !ok {
+				netIndex[wire] = len(nets)
+				nets = append(nets, wire)
+			}
+		}
+	}
+
+	order, _ := simulator.gateOrder()
+	gateIndex := make([]int, len(simulator.gates))
+	for i, gate := range order {
+		gateIndex[gate] = i
+	}
+
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(out, "// Code generated by BitmapLogicSimulator compile. DO NOT EDIT.\n\n")
+	fmt.Fprintf(out, "package %s\n\n", packageName)
+
+	fmt.Fprintf(out, "const (\n")
+	fmt.Fprintf(out, "\tWidth     = %d\n", simulator.width)
+	fmt.Fprintf(out, "\tHeight    = %d\n", simulator.height)
+	fmt.Fprintf(out, "\tNetCount  = %d\n", len(nets))
+	fmt.Fprintf(out, "\tGateCount = %d\n", len(order))
+	fmt.Fprintf(out, ")\n\n")
+
+	fmt.Fprintf(out, "type Circuit struct {\n")
+	fmt.Fprintf(out, "\tnets  [%d]uint64\n", (len(nets)+63)/64)
+	fmt.Fprintf(out, "\tgates [%d]uint64\n", (len(order)+63)/64)
+	fmt.Fprintf(out, "}\n\n")
+
+	fmt.Fprintf(out, "func New() *Circuit {\n\treturn new(Circuit)\n}\n\n")
+
+	fmt.Fprintf(out, "func (c *Circuit) Size() (int, int) {\n\treturn Width, Height\n}\n\n")
+
+	// step
+	chunks := (len(order) + CODEGEN_GATES_PER_FUNC - 1) / CODEGEN_GATES_PER_FUNC
+	fmt.Fprintf(out, "// Step evaluates every gate once, then drives the nets from the gates.\n")
+	fmt.Fprintf(out, "func (c *Circuit) Step() {\n")
+	for i := 0; i < chunks; i++ {
+		fmt.Fprintf(out, "\tc.gates%d()\n", i)
+	}
+	fmt.Fprintf(out, "\tc.store()\n")
+	fmt.Fprintf(out, "}\n\n")
+
+	fmt.Fprintf(out, "// Simulate is Step, named after Simulator.Simulate.\n")
+	fmt.Fprintf(out, "func (c *Circuit) Simulate() {\n\tc.Step()\n}\n\n")
+
+	for i := 0; i < chunks; i++ {
+		fmt.Fprintf(out, "func (c *Circuit) gates%d() {\n", i)
+		fmt.Fprintf(out, "\tvar in uint64\n")
+
+		end := (i + 1) * CODEGEN_GATES_PER_FUNC
+		if end > len(order) {
+			end = len(order)
+		}
+		for index := i * CODEGEN_GATES_PER_FUNC; index < end; index++ {
+			g := simulator.gates[order[index]]
+			center := g.center()
+
+			fmt.Fprintf(out, "\n\t// %d,%d\n", center.x, center.y)
+			if len(g.inGates) == 0 {
+				writeLoad(out, "nets", []int{netIndex[g.inIdx]})
+			} else {
+				inputs := make([]int, len(g.inGates))
+				for i, inGate := range g.inGates {
+					inputs[i] = gateIndex[inGate]
+				}
+				writeLoad(out, "gates", inputs)
+			}
+			if g.buffer {
+				fmt.Fprintf(out, "\tc.gates[%d] = c.gates[%d]&^(1<<%d) | (in&1)<<%d\n", index>>6, index>>6, index&63, index&63)
+			} else {
+				fmt.Fprintf(out, "\tc.gates[%d] = c.gates[%d]&^(1<<%d) | (^in&1)<<%d\n", index>>6, index>>6, index&63, index&63)
+			}
+		}
+
+		fmt.Fprintf(out, "}\n\n")
+	}
+
+	// nets driven by gates
+	drivers := make(map[int][]int)
+	for i, g := range simulator.gates {
+		drivers[g.outIdx] = append(drivers[g.outIdx], i)
+	}
+	driven := make([]int, 0, len(drivers))
+	for wire := range drivers {
+		driven = append(driven, netIndex[wire])
+	}
+	sort.Ints(driven)
+
+	fmt.Fprintf(out, "func (c *Circuit) store() {\n")
+	if len(driven) > 0 {
+		fmt.Fprintf(out, "\tvar in uint64\n")
+	}
+	for _, index := range driven {
+		inputs := make([]int, 0)
+		for _, gate := range drivers[nets[index]] {
+			inputs = append(inputs, gateIndex[gate])
+		}
+		fmt.Fprintf(out, "\n")
+		writeLoad(out, "gates", inputs)
+		fmt.Fprintf(out, "\tc.nets[%d] = c.nets[%d]&^(1<<%d) | (in&1)<<%d\n", index>>6, index>>6, index&63, index&63)
+	}
+	fmt.Fprintf(out, "}\n\n")
+
+	// pixel to net spans
+	fmt.Fprintf(out, "type span struct {\n\tx0, x1 int32\n\tnet    int32\n}\n\n")
+	fmt.Fprintf(out, "var rows = [Height + 1]int32{")
+	spans := make([][3]int, 0)
+	for y := 0; y < simulator.height; y++ {
+		if y%16 == 0 {
+			fmt.Fprintf(out, "\n\t")
+		} else {
+			fmt.Fprintf(out, " ")
+		}
+		fmt.Fprintf(out, "%d,", len(spans))
+
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.wireAt(x, y)
+			if wire < 0 {
+				continue
+			}
+
+			last := len(spans) - 1
+			if x > 0 && simulator.wireAt(x-1, y) == wire && last >= 0 {
+				spans[last][1] = x
+			} else {
+				spans = append(spans, [3]int{x, x, netIndex[wire]})
+			}
+		}
+	}
+	fmt.Fprintf(out, "\n\t%d,\n}\n\n", len(spans))
+
+	fmt.Fprintf(out, "var spans = [...]span{\n")
+	for _, s := range spans {
+		fmt.Fprintf(out, "\t{%d, %d, %d},\n", s[0], s[1], s[2])
+	}
+	fmt.Fprintf(out, "}\n\n")
+
+	fmt.Fprintf(out, `// NetAt returns the net index under a pixel, -1 for insulation.
+func NetAt(x, y int) int {
+	if x < 0 || y < 0 || x >= Width || y >= Height {
+		return -1
+	}
+
+	lo, hi := int(rows[y]), int(rows[y+1])
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case int(spans[mid].x1) < x:
+			lo = mid + 1
+		case int(spans[mid].x0) > x:
+			hi = mid
+		default:
+			return int(spans[mid].net)
+		}
+	}
+
+	return -1
+}
+
+func (c *Circuit) GetNet(net int) bool {
+	return c.nets[net>>6]&(1<<uint(net&63)) != 0
+}
+
+func (c *Circuit) SetNet(net int, state bool) {
+	if state {
+		c.nets[net>>6] |= 1 << uint(net&63)
+	} else {
+		c.nets[net>>6] &^= 1 << uint(net&63)
+	}
+}
+
+func (c *Circuit) Set(x, y int, state bool) bool {
+	net := NetAt(x, y)
+	if net < 0 {
+		return false
+	}
+
+	c.SetNet(net, state)
+
+	return true
+}
+
+func (c *Circuit) Get(x, y int) bool {
+	net := NetAt(x, y)
+	if net < 0 {
+		return false
+	}
+
+	return c.GetNet(net)
+}
+`)
+
+	return out.Flush()
+}
+
+// writeLoad emits statements ORing the given bits of a state array into the
+// lowest bit of in.
+func writeLoad(out io.Writer, array string, bits []int) {
+	for i, bit := range bits {
+		op := "="
+		if i > 0 {
+			op = "|="
+		}
+
+		if bit&63 == 0 {
+			fmt.Fprintf(out, "\tin %s c.%s[%d]\n", op, array, bit>>6)
+		} else {
+			fmt.Fprintf(out, "\tin %s c.%s[%d] >> %d\n", op, array, bit>>6, bit&63)
+		}
+	}
+}
+
+// gateOrder orders the gates so that drivers come before the gates reading
+// them. Edges closing a feedback loop are ignored; cyclic reports whether
+// there were any.
+func (simulator *Simulator) gateOrder() (order []int, cyclic bool) {
+	readers := simulator.netReaders()
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make([]int, len(simulator.gates))
+	postorder := make([]int, 0, len(simulator.gates))
+
+	type frame struct {
+		gate int
+		next int
+	}
+	for root := range simulator.gates {
+		if marks[root] != unvisited {
+			continue
+		}
+
+		stack := []frame{{root, 0}}
+		marks[root] = visiting
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			fanout := readers[simulator.gates[top.gate].outIdx]
+
+			if top.next < len(fanout) {
+				next := fanout[top.next]
+				top.next++
+
+				switch marks[next] {
+				case unvisited:
+					marks[next] = visiting
+					stack = append(stack, frame{next, 0})
+				case visiting:
+					cyclic = true
+				}
+				continue
+			}
+
+			marks[top.gate] = visited
+			postorder = append(postorder, top.gate)
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	order = make([]int, len(postorder))
+	for i, gate := range postorder {
+		order[len(postorder)-1-i] = gate
+	}
+
+	return order, cyclic
+}
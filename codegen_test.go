@@ -0,0 +1,197 @@
+package gobls_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func TestGenerateGo(t *testing.T) {
+	// inverter chain feeding an AND gate, plus a ring oscillator
+	b := builder.New(32, 24)
+	b.Pin("a", 1, 2)
+	b.Wire(1, 2, 2, 2)
+	b.Not(3, 2, gobls.DIR_RIGHT)
+	b.Not(6, 2, gobls.DIR_RIGHT)
+	ports := b.And(10, 0, gobls.DIR_RIGHT)
+	b.Wire(7, 2, 11, 2)
+	b.Pin("b", ports.In[1].X, ports.In[1].Y)
+	b.Not(3, 14, gobls.DIR_RIGHT)
+	b.Not(6, 14, gobls.DIR_RIGHT)
+	b.Not(9, 14, gobls.DIR_RIGHT)
+	b.Wire(10, 14, 12, 17)
+	b.Wire(12, 17, 2, 17)
+	b.Wire(2, 17, 2, 14)
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(b.Image())
+
+	var buf bytes.Buffer
+	err := simulator.GenerateGo(&buf, "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(formatted, buf.Bytes()) {
+		t.Error("generated code is not gofmt formatted")
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "cpu.go", buf.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Name.Name != "cpu" {
+		t.Errorf("package = %s", file.Name.Name)
+	}
+
+	conf := types.Config{}
+	pkg, err := conf.Check("cpu", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	circuit := pkg.Scope().Lookup("Circuit")
+	if circuit == nil {
+		t.Fatal("no Circuit type")
+	}
+	methods := types.NewMethodSet(types.NewPointer(circuit.Type()))
+	for _, name := range []string{"Step", "Simulate", "Set", "Get", "Size", "GetNet", "SetNet"} {
+		if methods.Lookup(pkg, name) == nil {
+			t.Errorf("Circuit has no method %s", name)
+		}
+	}
+}
+
+// codegenDriver sets the inputs a and b of the generated circuit to every
+// combination and prints the state of every pixel after stepping.
+const codegenDriver = `package main
+
+import (
+	"fmt"
+
+	"gen/cpu"
+)
+
+func main() {
+	c := new(cpu.Circuit)
+	for v := 0; v < 4; v++ {
+		for i := 0; i < 10; i++ {
+			c.Set(%d, %d, v&1 != 0)
+			c.Set(%d, %d, v&2 != 0)
+			c.Step()
+		}
+
+		for y := 0; y < cpu.Height; y++ {
+			for x := 0; x < cpu.Width; x++ {
+				if c.Get(x, y) {
+					fmt.Print("1")
+				} else {
+					fmt.Print("0")
+				}
+			}
+		}
+		fmt.Println()
+	}
+}
+`
+
+func TestGeneratedCircuitRuns(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go tool to build the generated code")
+	}
+
+	// inverter chain feeding an AND gate
+	b := builder.New(24, 12)
+	b.Wire(1, 2, 2, 2)
+	b.Not(3, 2, gobls.DIR_RIGHT)
+	b.Not(6, 2, gobls.DIR_RIGHT)
+	ports := b.And(10, 0, gobls.DIR_RIGHT)
+	b.Wire(7, 2, 11, 2)
+	b.Wire(ports.Out.X, ports.Out.Y, 23, ports.Out.Y)
+	a, bIn := gobls.Pin{1, 2}, gobls.Pin{ports.In[1].X, ports.In[1].Y}
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(b.Image())
+
+	dir, err := ioutil.TempDir("", "codegen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.Mkdir(filepath.Join(dir, "cpu"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = simulator.GenerateGo(&buf, "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"go.mod":     "module gen\n\ngo 1.16\n",
+		"main.go":    fmt.Sprintf(codegenDriver, a.X, a.Y, bIn.X, bIn.Y),
+		"cpu/cpu.go": buf.String(),
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goTool, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=", "GO111MODULE=on")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+	generated := strings.Fields(string(output))
+	if len(generated) != 4 {
+		t.Fatalf("driver output %q", output)
+	}
+
+	width, height := simulator.Size()
+	for v := 0; v < 4; v++ {
+		for i := 0; i < 50; i++ {
+			simulator.Set(a.X, a.Y, v&1 != 0)
+			simulator.Set(bIn.X, bIn.Y, v&2 != 0)
+			simulator.Simulate()
+		}
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				got := generated[v][y*width+x] == '1'
+				if got != simulator.Get(x, y) {
+					t.Errorf("a=%d b=%d: pixel %d,%d is %v in the generated circuit", v&1, v>>1, x, y, got)
+				}
+			}
+		}
+	}
+
+	// the AND output follows both inputs
+	out := ports.Out
+	if generated[3][out.Y*width+out.X] != '1' || generated[1][out.Y*width+out.X] != '0' {
+		t.Error("generated AND gate output wrong")
+	}
+}
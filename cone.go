@@ -0,0 +1,159 @@
+package gobls
+
+// ConeGate is a gate of a cone or trace.
+type ConeGate struct {
+	Center Pin
+	Level  int // 1 for the gates at the start net, 2 for the gates one further
+}
+
+// Cone is the part of the circuit that can change a net, its fan-in, or
+// that a net can change, its fan-out.
+type Cone struct {
+	Gates []ConeGate // nearest first
+	Nets  []Pin      // a pixel of every net reached, the start net first
+}
+
+// FanIn returns the gates and nets that can change the net under a pixel
+// through at most levels gates, all of them when levels is 0 or less.
+func (simulator *Simulator) FanIn(x, y, levels int) (*Cone, bool) {
+	return simulator.cone(x, y, levels, true)
+}
+
+// FanOut returns the gates and nets the net under a pixel can change through
+// at most levels gates, all of them when levels is 0 or less.
+func (simulator *Simulator) FanOut(x, y, levels int) (*Cone, bool) {
+	return simulator.cone(x, y, levels, false)
+}
+
+// cone walks the gate graph breadth first, against the signal flow for the
+// fan-in.
+func (simulator *Simulator) cone(x, y, levels int, fanIn bool) (*Cone, bool) {
+	start := simulator.wireAt(x, y)
+	if start < 0 {
+		return nil, false
+	}
+
+	next := simulator.netReaders()
+	if fanIn {
+		next = simulator.netDrivers()
+	}
+
+	cone := &Cone{Gates: make([]ConeGate, 0), Nets: []Pin{{x, y}}}
+	seenNets := map[int]bool{start: true}
+	seenGates := make([]bool, len(simulator.gates))
+
+	frontier := []int{start}
+	for level := 1; len(frontier) > 0 && (levels <= 0 || level <= levels); level++ {
+		nets := make([]int, 0)
+		for _, net := range frontier {
+			for _, i := range next[net] {
+				if seenGates[i] {
+					continue
+				}
+				seenGates[i] = true
+
+				g := simulator.gates[i]
+				cone.Gates = append(cone.Gates, ConeGate{Pin{g.at.x, g.at.y}, level})
+
+				far, pixel := g.outIdx, g.out
+				if fanIn {
+					far, pixel = g.inIdx, g.in
+				}
+				if far >= 0 && !seenNets[far] {
+					seenNets[far] = true
+					cone.Nets = append(cone.Nets, Pin{pixel.x, pixel.y})
+					nets = append(nets, far)
+				}
+			}
+		}
+		frontier = nets
+	}
+
+	return cone, true
+}
+
+// Trace is the reason for the state of a net: the gates keeping it and the
+// nets it comes from.
+type Trace struct {
+	State   bool
+	Gates   []ConeGate    // nearest first
+	Sources []TraceSource // nets no gate keeps at their state
+}
+
+// TraceSource is a net whose state is set from outside the gates: by a pin,
+// a device, a force or a stuck-at fault.
+type TraceSource struct {
+	Pin   Pin // a pixel of the net
+	State bool
+	Held  bool // forced or stuck by a fault
+}
+
+// Trace walks back from the net under a pixel through the gates keeping its
+// current state. A high net is kept by its high drivers, a low net by all of
+// its drivers; a gate is kept by its input net. Nets without such drivers
+// are the sources of the state. Gates stuck by a fault end the walk.
+func (simulator *Simulator) Trace(x, y int) (*Trace, bool) {
+	start := simulator.wireAt(x, y)
+	if start < 0 {
+		return nil, false
+	}
+
+	drivers := simulator.netDrivers()
+
+	trace := &Trace{
+		State:   simulator.states[start],
+		Gates:   make([]ConeGate, 0),
+		Sources: make([]TraceSource, 0),
+	}
+	seenNets := map[int]bool{start: true}
+	seenGates := make([]bool, len(simulator.gates))
+
+	type step struct {
+		net   int
+		pixel point
+	}
+	frontier := []step{{start, point{x, y}}}
+	for level := 1; len(frontier) > 0; level++ {
+		nets := make([]step, 0)
+		for _, s := range frontier {
+			state := simulator.states[s.net]
+
+			_, forced := simulator.forcedNets[s.net]
+			_, stuck := simulator.netFaults[s.net]
+			keeping := make([]int, 0)
+			if !forced && !stuck {
+				for _, i := range drivers[s.net] {
+					if !state || simulator.gates[i].state {
+						keeping = append(keeping, i)
+					}
+				}
+			}
+
+			if len(keeping) == 0 {
+				trace.Sources = append(trace.Sources, TraceSource{Pin{s.pixel.x, s.pixel.y}, state, forced || stuck})
+				continue
+			}
+
+			for _, i := range keeping {
+				if seenGates[i] {
+					continue
+				}
+				seenGates[i] = true
+
+				g := simulator.gates[i]
+				trace.Gates = append(trace.Gates, ConeGate{Pin{g.at.x, g.at.y}, level})
+
+				if _, ok := simulator.gateFaults[i]; ok {
+					continue
+				}
+				if g.inIdx >= 0 && !seenNets[g.inIdx] {
+					seenNets[g.inIdx] = true
+					nets = append(nets, step{g.inIdx, g.in})
+				}
+			}
+		}
+		frontier = nets
+	}
+
+	return trace, true
+}
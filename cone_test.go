@@ -0,0 +1,97 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+// coneCircuit has two chains of two NOT gates driving the same output net.
+func coneCircuit() *gobls.Simulator {
+	b := builder.New(14, 9)
+	b.Wire(0, 2, 2, 2)
+	b.Not(3, 2, gobls.DIR_RIGHT)
+	b.Not(6, 2, gobls.DIR_RIGHT)
+	b.Wire(7, 2, 12, 2)
+
+	b.Wire(0, 6, 2, 6)
+	b.Not(3, 6, gobls.DIR_RIGHT)
+	b.Not(6, 6, gobls.DIR_RIGHT)
+	b.Wire(7, 6, 11, 2)
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(b.Image())
+
+	return simulator
+}
+
+func TestFanInOut(t *testing.T) {
+	simulator := coneCircuit()
+
+	in, ok := simulator.FanIn(12, 2, 0)
+	if !ok {
+		t.Fatal("no cone at the output")
+	}
+	want := []gobls.ConeGate{{gobls.Pin{6, 2}, 1}, {gobls.Pin{6, 6}, 1}, {gobls.Pin{3, 2}, 2}, {gobls.Pin{3, 6}, 2}}
+	if len(in.Gates) != len(want) || len(in.Nets) != 5 {
+		t.Fatalf("fan-in gates %v nets %v", in.Gates, in.Nets)
+	}
+	for i := range want {
+		if in.Gates[i] != want[i] {
+			t.Fatalf("fan-in gates %v, want %v", in.Gates, want)
+		}
+	}
+
+	if near, _ := simulator.FanIn(12, 2, 1); len(near.Gates) != 2 || len(near.Nets) != 3 {
+		t.Errorf("one level fan-in gates %v nets %v", near.Gates, near.Nets)
+	}
+
+	out, _ := simulator.FanOut(0, 2, 0)
+	if len(out.Gates) != 2 || out.Gates[1] != (gobls.ConeGate{gobls.Pin{6, 2}, 2}) || len(out.Nets) != 3 {
+		t.Errorf("fan-out gates %v nets %v", out.Gates, out.Nets)
+	}
+
+	if _, ok := simulator.FanOut(0, 0, 0); ok {
+		t.Error("cone on insulation")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	simulator := coneCircuit()
+	simulator.Set(0, 2, true)
+	for i := 0; i < 100; i++ {
+		simulator.Simulate()
+	}
+
+	// only the upper chain keeps the output high
+	trace, ok := simulator.Trace(12, 2)
+	if !ok || !trace.State {
+		t.Fatalf("trace %+v", trace)
+	}
+	if len(trace.Gates) != 2 || trace.Gates[0].Center != (gobls.Pin{6, 2}) || trace.Gates[1].Center != (gobls.Pin{3, 2}) {
+		t.Errorf("trace gates %v", trace.Gates)
+	}
+	if len(trace.Sources) != 1 || trace.Sources[0] != (gobls.TraceSource{gobls.Pin{2, 2}, true, false}) {
+		t.Errorf("trace sources %v", trace.Sources)
+	}
+
+	// both chains keep it low
+	simulator.Set(0, 2, false)
+	for i := 0; i < 100; i++ {
+		simulator.Simulate()
+	}
+	if low, _ := simulator.Trace(12, 2); low.State || len(low.Gates) != 4 || len(low.Sources) != 2 {
+		t.Errorf("low trace %+v", low)
+	}
+
+	// a forced net is a source
+	simulator.Force(4, 2, false)
+	for i := 0; i < 100; i++ {
+		simulator.Simulate()
+	}
+	forced, _ := simulator.Trace(12, 2)
+	if !forced.State || len(forced.Gates) != 1 || len(forced.Sources) != 1 || !forced.Sources[0].Held {
+		t.Errorf("forced trace %+v", forced)
+	}
+}
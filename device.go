@@ -0,0 +1,94 @@
+package gobls
+
+import (
+	"io"
+)
+
+// Pin is a pixel coordinate used to reach the net under it.
+type Pin struct {
+	X, Y int
+}
+
+// Bus is an ordered group of pins. The first pin is the least significant bit.
+type Bus []Pin
+
+// Device is a component attached to the simulator which is not drawn in the
+// bitmap. Devices are stepped before every gate sweep.
+type Device interface {
+	Step(simulator *Simulator)
+}
+
+// Resetter is a device that can return to the state it was built in, such
+// as a memory to its loaded contents.
+type Resetter interface {
+	Reset()
+}
+
+func (simulator *Simulator) Attach(device Device) {
+	simulator.devices = append(simulator.devices, device)
+}
+
+func (simulator *Simulator) Detach(device Device) {
+	for i, d := range simulator.devices {
+		if d == device {
+			simulator.devices = append(simulator.devices[:i], simulator.devices[i+1:]...)
+			return
+		}
+	}
+}
+
+func (simulator *Simulator) Devices() []Device {
+	return simulator.devices
+}
+
+// DetachAll removes every device and closes the ones holding resources.
+func (simulator *Simulator) DetachAll() error {
+	var firstErr error
+
+	for _, device := range simulator.devices {
+		if closer, ok := device.(io.Closer); ok {
+			err := closer.Close()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	simulator.devices = nil
+
+	return firstErr
+}
+
+func (simulator *Simulator) ReadBus(bus Bus) uint64 {
+	value := uint64(0)
+
+	for i, pin := range bus {
+		if simulator.Get(pin.X, pin.Y) {
+			value |= 1 << uint(i)
+		}
+	}
+
+	return value
+}
+
+func (simulator *Simulator) WriteBus(bus Bus, value uint64) {
+	for i, pin := range bus {
+		simulator.Set(pin.X, pin.Y, value&(1<<uint(i)) != 0)
+	}
+}
+
+// busEnabled reports whether every pin of an enable group is high.
+// An empty group gives the default value.
+func (simulator *Simulator) busEnabled(bus Bus, def bool) bool {
+	if len(bus) == 0 {
+		return def
+	}
+
+	for _, pin := range bus {
+		if !simulator.Get(pin.X, pin.Y) {
+			return false
+		}
+	}
+
+	return true
+}
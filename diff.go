@@ -0,0 +1,158 @@
+package gobls
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	DIFF_GATE_ADDED       = "gate-added"
+	DIFF_GATE_REMOVED     = "gate-removed"
+	DIFF_GATE_REORIENTED  = "gate-reoriented"
+	DIFF_CROSSING_ADDED   = "crossing-added"
+	DIFF_CROSSING_REMOVED = "crossing-removed"
+	DIFF_NET_MERGED       = "net-merged" // nets of the first image connected in the second
+	DIFF_NET_SPLIT        = "net-split"  // net of the first image broken apart in the second
+)
+
+var dirNames = [...]string{"up", "right", "down", "left"}
+
+// Difference is a change of the extracted circuit between two images.
+type Difference struct {
+	Kind    string
+	X, Y    int
+	Message string
+	Pixels  []Pin // changed region
+}
+
+func (difference Difference) String() string {
+	return fmt.Sprintf("%d,%d: %s: %s", difference.X, difference.Y, difference.Kind, difference.Message)
+}
+
+// Diff compares the circuits extracted from two images. Gates and crossings
+// are matched by position. Nets are compared on the pixels conductive in
+// both images, so drawing or erasing a wire only shows up when it changes
+// how those pixels are connected.
+func Diff(a, b *Simulator) []Difference {
+	differences := make([]Difference, 0)
+
+	// gates
+	gatesA := make(map[point]*gate)
+	for _, g := range a.gates {
+		gatesA[g.center()] = g
+	}
+	gatesB := make(map[point]*gate)
+	for _, g := range b.gates {
+		gatesB[g.center()] = g
+	}
+
+	for center, g := range gatesA {
+		other, ok := gatesB[center]
+		if !ok {
+			differences = append(differences, Difference{DIFF_GATE_REMOVED, center.x, center.y,
+				fmt.Sprintf("gate pointing %s removed", dirNames[g.dir]), blockPixels(center)})
+		} else if other.dir != g.dir {
+			differences = append(differences, Difference{DIFF_GATE_REORIENTED, center.x, center.y,
+				fmt.Sprintf("gate turned from %s to %s", dirNames[g.dir], dirNames[other.dir]), blockPixels(center)})
+		}
+	}
+	for center, g := range gatesB {
+		if _, ok := gatesA[center]; !ok {
+			differences = append(differences, Difference{DIFF_GATE_ADDED, center.x, center.y,
+				fmt.Sprintf("gate pointing %s added", dirNames[g.dir]), blockPixels(center)})
+		}
+	}
+
+	// crossings
+	crossingsA := make(map[point]bool)
+	for _, p := range a.crossings {
+		crossingsA[p] = true
+	}
+	crossingsB := make(map[point]bool)
+	for _, p := range b.crossings {
+		crossingsB[p] = true
+		if !crossingsA[p] {
+			differences = append(differences, Difference{DIFF_CROSSING_ADDED, p.x, p.y, "crossing added", blockPixels(p)})
+		}
+	}
+	for _, p := range a.crossings {
+		if !crossingsB[p] {
+			differences = append(differences, Difference{DIFF_CROSSING_REMOVED, p.x, p.y, "crossing removed", blockPixels(p)})
+		}
+	}
+
+	// nets, over the pixels conductive in both images
+	width, height := a.width, a.height
+	if b.width < width {
+		width = b.width
+	}
+	if b.height < height {
+		height = b.height
+	}
+
+	type region struct {
+		first  Pin
+		pixels []Pin
+		others map[int]bool
+	}
+	regionsA := make(map[int]*region)
+	regionsB := make(map[int]*region)
+	regionOf := func(regions map[int]*region, wire int, p Pin) *region {
+		r, ok := regions[wire]
+		if !ok {
+			r = &region{first: p, others: make(map[int]bool)}
+			regions[wire] = r
+		}
+		r.pixels = append(r.pixels, p)
+		return r
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			wireA, wireB := a.wireAt(x, y), b.wireAt(x, y)
+			if wireA < 0 || wireB < 0 {
+				continue
+			}
+
+			regionOf(regionsA, wireA, Pin{x, y}).others[wireB] = true
+			regionOf(regionsB, wireB, Pin{x, y}).others[wireA] = true
+		}
+	}
+
+	for _, r := range regionsA {
+		if len(r.others) > 1 {
+			differences = append(differences, Difference{DIFF_NET_SPLIT, r.first.X, r.first.Y,
+				fmt.Sprintf("net split into %d nets", len(r.others)), r.pixels})
+		}
+	}
+	for _, r := range regionsB {
+		if len(r.others) > 1 {
+			differences = append(differences, Difference{DIFF_NET_MERGED, r.first.X, r.first.Y,
+				fmt.Sprintf("%d nets merged", len(r.others)), r.pixels})
+		}
+	}
+
+	sort.SliceStable(differences, func(i, j int) bool {
+		if differences[i].Y != differences[j].Y {
+			return differences[i].Y < differences[j].Y
+		}
+		if differences[i].X != differences[j].X {
+			return differences[i].X < differences[j].X
+		}
+		return differences[i].Kind < differences[j].Kind
+	})
+
+	return differences
+}
+
+// blockPixels returns the 3x3 block around a gate or crossing center.
+func blockPixels(center point) []Pin {
+	pixels := make([]Pin, 0, 9)
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			pixels = append(pixels, Pin{center.x + dx, center.y + dy})
+		}
+	}
+
+	return pixels
+}
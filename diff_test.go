@@ -0,0 +1,55 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func TestDiff(t *testing.T) {
+	a := builder.New(32, 10)
+	a.Wire(1, 1, 3, 1)
+	a.Wire(5, 1, 7, 1)
+	a.Wire(10, 1, 14, 1)
+	a.Not(3, 5, gobls.DIR_RIGHT)
+	a.Crossing(20, 5)
+	a.Not(25, 5, gobls.DIR_UP)
+
+	b := builder.New(32, 10)
+	b.Wire(1, 1, 7, 1)
+	b.Wire(10, 1, 11, 1)
+	b.Wire(13, 1, 14, 1)
+	b.Not(3, 5, gobls.DIR_LEFT)
+	b.Not(14, 5, gobls.DIR_DOWN)
+	b.Crossing(10, 5)
+	b.Crossing(20, 5)
+	b.Not(25, 5, gobls.DIR_UP)
+
+	differences := gobls.Diff(loadBuilder(a), loadBuilder(b))
+
+	want := map[string]gobls.Pin{
+		gobls.DIFF_NET_MERGED:      {1, 1},
+		gobls.DIFF_NET_SPLIT:       {10, 1},
+		gobls.DIFF_GATE_REORIENTED: {3, 5},
+		gobls.DIFF_GATE_ADDED:      {14, 5},
+		gobls.DIFF_CROSSING_ADDED:  {10, 5},
+	}
+	for _, difference := range differences {
+		if difference.X >= 18 {
+			t.Errorf("difference in the unchanged part: %v", difference)
+		}
+
+		pin, ok := want[difference.Kind]
+		if ok && pin.X == difference.X && pin.Y == difference.Y {
+			delete(want, difference.Kind)
+		}
+	}
+	for kind, pin := range want {
+		t.Errorf("missing %s at %d,%d in %v", kind, pin.X, pin.Y, differences)
+	}
+
+	if differences := gobls.Diff(loadBuilder(a), loadBuilder(a)); len(differences) != 0 {
+		t.Errorf("differences of an image with itself: %v", differences)
+	}
+}
@@ -0,0 +1,256 @@
+package gobls
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+)
+
+const (
+	DISPLAY_PLOT    = iota // x, y and color buses latched by a write strobe
+	DISPLAY_GRID           // one pin per pixel, row major
+	DISPLAY_SEGMENT        // seven segment digit, pins a to g and an optional dot
+)
+
+const (
+	SEGMENT_WIDTH  = 12
+	SEGMENT_HEIGHT = 20
+)
+
+var (
+	DISPLAY_ON  = color.RGBA{255, 255, 255, 255}
+	DISPLAY_OFF = color.RGBA{0, 0, 0, 255}
+
+	SEGMENT_ON  = color.RGBA{255, 40, 20, 255}
+	SEGMENT_OFF = color.RGBA{50, 10, 10, 255}
+)
+
+// segment rectangles in a SEGMENT_WIDTH x SEGMENT_HEIGHT cell, a to g then dot
+var segmentRects = []image.Rectangle{
+	image.Rect(3, 1, 9, 3),     // a
+	image.Rect(8, 2, 10, 10),   // b
+	image.Rect(8, 10, 10, 18),  // c
+	image.Rect(3, 17, 9, 19),   // d
+	image.Rect(2, 10, 4, 18),   // e
+	image.Rect(2, 2, 4, 10),    // f
+	image.Rect(3, 9, 9, 11),    // g
+	image.Rect(10, 17, 12, 19), // dot
+}
+
+// Display renders nets into an image.
+//
+// In DISPLAY_PLOT mode a rising edge on Write stores the color read from
+// Color at the position read from X and Y. In DISPLAY_GRID mode Grid has
+// Width*Height pins, one per pixel. In DISPLAY_SEGMENT mode Grid holds the
+// seven segments a to g, optionally followed by the dot.
+//
+// If Output is set the image is written there as PNG when the display is
+// closed.
+type Display struct {
+	Mode int
+
+	X, Y, Color, Write Bus
+	Grid               Bus
+
+	Output string
+
+	img       *image.RGBA
+	prevWrite bool
+}
+
+type displayConfig struct {
+	Mode          string // "plot", "grid" or "segment"
+	Width, Height int
+
+	X, Y, Color, Write string
+	Grid               string
+	Segments           string
+
+	Output string
+}
+
+func init() {
+	RegisterDevice("display", buildDisplay)
+}
+
+func buildDisplay(manifest *Manifest, raw json.RawMessage) (Device, error) {
+	config := displayConfig{}
+	err := json.Unmarshal(raw, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	buses := make(map[string]Bus)
+	for _, name := range []string{config.X, config.Y, config.Color, config.Write, config.Grid, config.Segments} {
+		bus, err := manifest.Bus(name)
+		if err != nil {
+			return nil, err
+		}
+		buses[name] = bus
+	}
+
+	var display *Display
+	switch config.Mode {
+	case "plot", "":
+		display, err = NewPlotDisplay(config.Width, config.Height, buses[config.X], buses[config.Y], buses[config.Color], buses[config.Write])
+	case "grid":
+		display, err = NewGridDisplay(config.Width, config.Height, buses[config.Grid])
+	case "segment":
+		display, err = NewSegmentDisplay(buses[config.Segments])
+	default:
+		err = fmt.Errorf("unknown display mode %q", config.Mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	display.Output = manifest.Path(config.Output)
+
+	return display, nil
+}
+
+func NewPlotDisplay(width, height int, x, y, c, write Bus) (*Display, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("display needs a size")
+	}
+	if len(write) == 0 {
+		return nil, errors.New("plot display needs a write pin")
+	}
+
+	display := newDisplay(DISPLAY_PLOT, width, height)
+	display.X = x
+	display.Y = y
+	display.Color = c
+	display.Write = write
+
+	return display, nil
+}
+
+func NewGridDisplay(width, height int, grid Bus) (*Display, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("display needs a size")
+	}
+	if len(grid) != width*height {
+		return nil, fmt.Errorf("grid display of %dx%d needs %d pins, got %d", width, height, width*height, len(grid))
+	}
+
+	display := newDisplay(DISPLAY_GRID, width, height)
+	display.Grid = grid
+
+	return display, nil
+}
+
+func NewSegmentDisplay(segments Bus) (*Display, error) {
+	if len(segments) != 7 && len(segments) != 8 {
+		return nil, fmt.Errorf("seven segment display needs 7 or 8 pins, got %d", len(segments))
+	}
+
+	display := newDisplay(DISPLAY_SEGMENT, SEGMENT_WIDTH, SEGMENT_HEIGHT)
+	display.Grid = segments
+
+	return display, nil
+}
+
+func newDisplay(mode, width, height int) *Display {
+	display := new(Display)
+	display.Mode = mode
+	display.img = image.NewRGBA(image.Rect(0, 0, width, height))
+	display.Reset()
+
+	return display
+}
+
+// Image returns the rendered image. It is updated in place by Step.
+func (display *Display) Image() *image.RGBA {
+	return display.img
+}
+
+func (display *Display) Step(simulator *Simulator) {
+	switch display.Mode {
+	case DISPLAY_PLOT:
+		write := simulator.busEnabled(display.Write, false)
+		if write && !display.prevWrite {
+			x := int(simulator.ReadBus(display.X))
+			y := int(simulator.ReadBus(display.Y))
+			c := busColor(simulator.ReadBus(display.Color), len(display.Color))
+
+			display.img.Set(x, y, c)
+		}
+		display.prevWrite = write
+	case DISPLAY_GRID:
+		width := display.img.Rect.Dx()
+		for i, pin := range display.Grid {
+			if simulator.Get(pin.X, pin.Y) {
+				display.img.SetRGBA(i%width, i/width, DISPLAY_ON)
+			} else {
+				display.img.SetRGBA(i%width, i/width, DISPLAY_OFF)
+			}
+		}
+	case DISPLAY_SEGMENT:
+		draw.Draw(display.img, display.img.Rect, image.NewUniform(DISPLAY_OFF), image.ZP, draw.Src)
+		for i, pin := range display.Grid {
+			c := SEGMENT_OFF
+			if simulator.Get(pin.X, pin.Y) {
+				c = SEGMENT_ON
+			}
+
+			draw.Draw(display.img, segmentRects[i], image.NewUniform(c), image.ZP, draw.Src)
+		}
+	}
+}
+
+// Reset clears the image and the write strobe.
+func (display *Display) Reset() {
+	draw.Draw(display.img, display.img.Rect, image.NewUniform(DISPLAY_OFF), image.ZP, draw.Src)
+	display.prevWrite = false
+}
+
+func (display *Display) Save(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	err = png.Encode(file, display.img)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Close writes the image to Output if it is set.
+func (display *Display) Close() error {
+	if display.Output == "" {
+		return nil
+	}
+
+	return display.Save(display.Output)
+}
+
+// busColor decodes a color bus. 3 bits are RGB, 8 bits are RRRGGGBB,
+// 24 bits are 8 bits per channel (blue in the low byte) and other widths
+// are gray levels.
+func busColor(value uint64, width int) color.RGBA {
+	switch width {
+	case 0:
+		return DISPLAY_ON
+	case 3:
+		return color.RGBA{uint8((value >> 2 & 1) * 255), uint8((value >> 1 & 1) * 255), uint8((value & 1) * 255), 255}
+	case 8:
+		return color.RGBA{uint8((value >> 5 & 7) * 255 / 7), uint8((value >> 2 & 7) * 255 / 7), uint8((value & 3) * 255 / 3), 255}
+	case 24:
+		return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 255}
+	}
+
+	max := float64(uint64(1)<<uint(width) - 1)
+	gray := uint8(float64(value) / max * 255)
+
+	return color.RGBA{gray, gray, gray, 255}
+}
@@ -0,0 +1,220 @@
+package gobls_test
+
+import (
+	"encoding/json"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+// pinRow loads n unconnected pixels, one every other column.
+func pinRow(n int) (*gobls.Simulator, gobls.Bus) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(strings.Repeat("#.", n)))
+
+	pins := make(gobls.Bus, n)
+	for i := range pins {
+		pins[i] = gobls.Pin{X: 2 * i, Y: 0}
+	}
+
+	return simulator, pins
+}
+
+func TestPlotDisplay(t *testing.T) {
+	simulator, pins := pinRow(5)
+	x, y, c, write := pins[0:2], pins[2:3], pins[3:4], pins[4:5]
+
+	display, err := gobls.NewPlotDisplay(4, 2, x, y, c, write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simulator.Attach(display)
+
+	simulator.WriteBus(x, 2)
+	simulator.WriteBus(y, 1)
+	simulator.WriteBus(c, 1)
+	simulator.Simulate()
+	if got := display.Image().RGBAAt(2, 1); got != gobls.DISPLAY_OFF {
+		t.Fatalf("plotted %v before the write strobe", got)
+	}
+
+	simulator.WriteBus(write, 1)
+	simulator.Simulate()
+	if got := display.Image().RGBAAt(2, 1); got != gobls.DISPLAY_ON {
+		t.Fatalf("plotted %v on the rising edge, want %v", got, gobls.DISPLAY_ON)
+	}
+
+	// a held strobe does not plot again
+	simulator.WriteBus(c, 0)
+	simulator.Simulate()
+	if got := display.Image().RGBAAt(2, 1); got != gobls.DISPLAY_ON {
+		t.Errorf("plotted %v while the strobe was held", got)
+	}
+
+	simulator.WriteBus(write, 0)
+	simulator.Simulate()
+	simulator.WriteBus(write, 1)
+	simulator.Simulate()
+	if got := display.Image().RGBAAt(2, 1); got != gobls.DISPLAY_OFF {
+		t.Errorf("plotted %v on the second rising edge, want %v", got, gobls.DISPLAY_OFF)
+	}
+}
+
+func TestDisplayColors(t *testing.T) {
+	cases := []struct {
+		width int
+		value uint64
+		want  color.RGBA
+	}{
+		{0, 0, gobls.DISPLAY_ON},
+		{3, 5, color.RGBA{255, 0, 255, 255}},
+		{8, 0xea, color.RGBA{255, 72, 170, 255}},
+		{24, 0x123456, color.RGBA{0x12, 0x34, 0x56, 255}},
+		{2, 2, color.RGBA{170, 170, 170, 255}},
+	}
+
+	for _, c := range cases {
+		simulator, pins := pinRow(c.width + 1)
+		write, bus := pins[:1], pins[1:]
+
+		display, err := gobls.NewPlotDisplay(1, 1, nil, nil, bus, write)
+		if err != nil {
+			t.Fatal(err)
+		}
+		simulator.Attach(display)
+
+		simulator.WriteBus(bus, c.value)
+		simulator.WriteBus(write, 1)
+		simulator.Simulate()
+
+		if got := display.Image().RGBAAt(0, 0); got != c.want {
+			t.Errorf("%d bits of %#x: color %v, want %v", c.width, c.value, got, c.want)
+		}
+	}
+}
+
+func TestGridDisplay(t *testing.T) {
+	simulator, pins := pinRow(6)
+
+	display, err := gobls.NewGridDisplay(3, 2, pins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simulator.Attach(display)
+
+	simulator.WriteBus(pins, 1<<1|1<<3)
+	simulator.Simulate()
+
+	// row major: pin 1 is the middle of the top row, pin 3 starts the bottom row
+	for i := range pins {
+		want := gobls.DISPLAY_OFF
+		if i == 1 || i == 3 {
+			want = gobls.DISPLAY_ON
+		}
+		if got := display.Image().RGBAAt(i%3, i/3); got != want {
+			t.Errorf("pixel %d,%d of pin %d: %v, want %v", i%3, i/3, i, got, want)
+		}
+	}
+}
+
+func TestSegmentDisplay(t *testing.T) {
+	for _, n := range []int{7, 8} {
+		simulator, pins := pinRow(n)
+
+		display, err := gobls.NewSegmentDisplay(pins)
+		if err != nil {
+			t.Fatal(err)
+		}
+		simulator.Attach(display)
+
+		// segment a and the dot
+		simulator.WriteBus(pins, 1|1<<7)
+		simulator.Simulate()
+
+		img := display.Image()
+		if got := img.RGBAAt(6, 2); got != gobls.SEGMENT_ON {
+			t.Errorf("%d pins: segment a %v, want %v", n, got, gobls.SEGMENT_ON)
+		}
+		if got := img.RGBAAt(9, 6); got != gobls.SEGMENT_OFF {
+			t.Errorf("%d pins: segment b %v, want %v", n, got, gobls.SEGMENT_OFF)
+		}
+
+		dot := gobls.DISPLAY_OFF
+		if n == 8 {
+			dot = gobls.SEGMENT_ON
+		}
+		if got := img.RGBAAt(11, 18); got != dot {
+			t.Errorf("%d pins: dot %v, want %v", n, got, dot)
+		}
+	}
+}
+
+func TestBuildDisplayErrors(t *testing.T) {
+	_, pins := pinRow(3)
+
+	manifest := gobls.NewManifest()
+	manifest.Pins["x"] = pins[0:1]
+	manifest.Pins["y"] = pins[1:2]
+	manifest.Pins["three"] = pins
+
+	configs := []string{
+		`{"Type": "display", "Mode": "vector", "Width": 4, "Height": 4}`,
+		`{"Type": "display", "Mode": "grid", "Width": 2, "Height": 2, "Grid": "three"}`,
+		`{"Type": "display", "Mode": "segment", "Segments": "three"}`,
+		`{"Type": "display", "Mode": "plot", "Width": 4, "Height": 4, "X": "x", "Y": "y"}`,
+	}
+	for _, config := range configs {
+		manifest.Devices = []json.RawMessage{json.RawMessage(config)}
+
+		simulator := gobls.NewSimulator()
+		if manifest.Attach(simulator) == nil {
+			t.Errorf("%s accepted", config)
+		}
+	}
+}
+
+func TestDisplaySave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "display")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	simulator, pins := pinRow(4)
+	display, err := gobls.NewGridDisplay(2, 2, pins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	display.Output = filepath.Join(dir, "display.png")
+	simulator.Attach(display)
+
+	simulator.WriteBus(pins, 1)
+	simulator.Simulate()
+	err = simulator.DetachAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(display.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)); got != gobls.DISPLAY_ON {
+		t.Errorf("saved pixel %v, want %v", got, gobls.DISPLAY_ON)
+	}
+
+	if display.Save(filepath.Join(dir, "missing", "display.png")) == nil {
+		t.Error("saved into a missing directory")
+	}
+}
@@ -0,0 +1,369 @@
+// Package editor edits circuit images with undo and redo.
+//
+// Edits are commands applied to a document. The document records the
+// pixels every command changed, so it can be undone and redone; commands
+// run between Begin and End are undone together, like the pixels of one
+// mouse stroke. Painting a conductive pixel keeps its color when it already
+// conducts, so colored sources survive editing around them.
+package editor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+// Command is an edit drawing through set.
+type Command interface {
+	Draw(set func(x, y int, conductive bool))
+}
+
+// Pixel paints or erases one pixel.
+type Pixel struct {
+	X, Y       int
+	Conductive bool
+}
+
+// Line paints or erases a straight line between two pixels, both included.
+type Line struct {
+	X0, Y0, X1, Y1 int
+	Conductive     bool
+}
+
+// Not stamps a NOT gate centered at (X, Y) with its output pointing to Dir.
+type Not struct {
+	X, Y, Dir int
+}
+
+// Crossing stamps a wire crossing centered at (X, Y).
+type Crossing struct {
+	X, Y int
+}
+
+func (pixel Pixel) Draw(set func(x, y int, conductive bool)) {
+	set(pixel.X, pixel.Y, pixel.Conductive)
+}
+
+func (line Line) Draw(set func(x, y int, conductive bool)) {
+	dx, dy := abs(line.X1-line.X0), -abs(line.Y1-line.Y0)
+	sx, sy := sign(line.X1-line.X0), sign(line.Y1-line.Y0)
+
+	// Bresenham
+	x, y, e := line.X0, line.Y0, dx+dy
+	for {
+		set(x, y, line.Conductive)
+		if x == line.X1 && y == line.Y1 {
+			return
+		}
+
+		if 2*e >= dy {
+			e += dy
+			x += sx
+		}
+		if 2*e <= dx {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+func (not Not) Draw(set func(x, y int, conductive bool)) {
+	b := builder.New(3, 3)
+	b.Not(1, 1, not.Dir)
+	stamp(b, not.X-1, not.Y-1, set)
+}
+
+func (crossing Crossing) Draw(set func(x, y int, conductive bool)) {
+	b := builder.New(3, 3)
+	b.Crossing(1, 1)
+	stamp(b, crossing.X-1, crossing.Y-1, set)
+}
+
+// stamp copies every pixel of a builder, insulation included.
+func stamp(b *builder.Builder, x, y int, set func(x, y int, conductive bool)) {
+	width, height := b.Size()
+	for by := 0; by < height; by++ {
+		for bx := 0; bx < width; bx++ {
+			set(x+bx, y+by, b.Get(bx, by))
+		}
+	}
+}
+
+// Document is an image being edited.
+type Document struct {
+	FileName   string
+	Conductive color.RGBA // color of painted pixels
+	Insulating color.RGBA // color of erased pixels
+
+	img *image.RGBA
+
+	undo  []*change
+	redo  []*change
+	group *change // open group between Begin and End
+
+	revision int // id of the last change applied, 0 for none
+	saved    int // revision of the saved file
+	changes  int // ids handed out
+}
+
+// change is an undoable set of pixel edits.
+type change struct {
+	id     int
+	pixels []pixelChange
+	bounds image.Rectangle
+}
+
+type pixelChange struct {
+	x, y          int
+	before, after color.RGBA
+}
+
+// New makes a document of a copy of an image.
+func New(img image.Image) *Document {
+	doc := new(Document)
+	doc.Conductive = color.RGBA{255, 255, 255, 255}
+	doc.Insulating = color.RGBA{0, 0, 0, 255}
+
+	bounds := img.Bounds()
+	doc.img = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(doc.img, doc.img.Rect, img, bounds.Min, draw.Src)
+
+	return doc
+}
+
+// Open reads an image file into a new document saved back to the same file.
+func Open(fileName string) (*Document, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := New(img)
+	doc.FileName = fileName
+
+	return doc, nil
+}
+
+// Image returns the edited image. It changes in place with every edit.
+func (doc *Document) Image() *image.RGBA {
+	return doc.img
+}
+
+// Matches tells if an image has the same pixels as the document.
+func (doc *Document) Matches(img image.Image) bool {
+	bounds := img.Bounds()
+	if bounds.Dx() != doc.img.Rect.Dx() || bounds.Dy() != doc.img.Rect.Dy() {
+		return false
+	}
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if color.RGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)) != doc.img.RGBAAt(x, y) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Modified tells if the document differs from the saved file.
+func (doc *Document) Modified() bool {
+	return doc.revision != doc.saved
+}
+
+// Save writes the document as a PNG file to FileName. The image is written
+// to a temporary file next to it first and renamed over FileName, so a
+// failed save leaves the old file whole and watchers never read half of it.
+func (doc *Document) Save() error {
+	file, err := ioutil.TempFile(filepath.Dir(doc.FileName), "."+filepath.Base(doc.FileName)+".*")
+	if err != nil {
+		return err
+	}
+
+	// keep the mode of the file replaced
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(doc.FileName); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	err = file.Chmod(mode)
+	if err == nil {
+		err = png.Encode(file, doc.img)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	err = os.Rename(file.Name(), doc.FileName)
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	doc.saved = doc.revision
+
+	return nil
+}
+
+// Begin groups the following commands into one undo step until End.
+func (doc *Document) Begin() {
+	if doc.group == nil {
+		doc.group = new(change)
+	}
+}
+
+// End closes the group of Begin and returns the region it changed.
+func (doc *Document) End() image.Rectangle {
+	group := doc.group
+	doc.group = nil
+
+	if group == nil || len(group.pixels) == 0 {
+		return image.Rectangle{}
+	}
+
+	doc.push(group)
+
+	return group.bounds
+}
+
+// Do applies a command and returns the region it changed. Commands not
+// changing any pixel are not recorded.
+func (doc *Document) Do(command Command) image.Rectangle {
+	c := doc.group
+	if c == nil {
+		c = new(change)
+	}
+
+	bounds := image.Rectangle{}
+	command.Draw(func(x, y int, conductive bool) {
+		if !(image.Point{x, y}).In(doc.img.Rect) {
+			return
+		}
+
+		before := doc.img.RGBAAt(x, y)
+		if isConductive(before) == conductive {
+			return
+		}
+
+		after := doc.Insulating
+		if conductive {
+			after = doc.Conductive
+		}
+
+		doc.img.SetRGBA(x, y, after)
+		c.pixels = append(c.pixels, pixelChange{x, y, before, after})
+		c.bounds = c.bounds.Union(image.Rect(x, y, x+1, y+1))
+		bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+	})
+
+	if doc.group == nil && len(c.pixels) > 0 {
+		doc.push(c)
+	}
+
+	return bounds
+}
+
+func (doc *Document) push(c *change) {
+	doc.changes++
+	c.id = doc.changes
+
+	doc.undo = append(doc.undo, c)
+	doc.redo = nil
+	doc.revision = c.id
+}
+
+func (doc *Document) CanUndo() bool {
+	return len(doc.undo) > 0
+}
+
+func (doc *Document) CanRedo() bool {
+	return len(doc.redo) > 0
+}
+
+// Undo reverts the last change and returns the region it changed. An open
+// group is closed first.
+func (doc *Document) Undo() (image.Rectangle, bool) {
+	doc.End()
+	if len(doc.undo) == 0 {
+		return image.Rectangle{}, false
+	}
+
+	c := doc.undo[len(doc.undo)-1]
+	doc.undo = doc.undo[:len(doc.undo)-1]
+	for i := len(c.pixels) - 1; i >= 0; i-- {
+		p := c.pixels[i]
+		doc.img.SetRGBA(p.x, p.y, p.before)
+	}
+	doc.redo = append(doc.redo, c)
+
+	doc.revision = 0
+	if len(doc.undo) > 0 {
+		doc.revision = doc.undo[len(doc.undo)-1].id
+	}
+
+	return c.bounds, true
+}
+
+// Redo applies the last undone change again.
+func (doc *Document) Redo() (image.Rectangle, bool) {
+	doc.End()
+	if len(doc.redo) == 0 {
+		return image.Rectangle{}, false
+	}
+
+	c := doc.redo[len(doc.redo)-1]
+	doc.redo = doc.redo[:len(doc.redo)-1]
+	for _, p := range c.pixels {
+		doc.img.SetRGBA(p.x, p.y, p.after)
+	}
+	doc.undo = append(doc.undo, c)
+	doc.revision = c.id
+
+	return c.bounds, true
+}
+
+// isConductive follows the simulator's rule.
+func isConductive(c color.RGBA) bool {
+	r, g, b, _ := c.RGBA()
+
+	return r > gobls.BRIGHT_MIN || g > gobls.BRIGHT_MIN || b > gobls.BRIGHT_MIN
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func sign(a int) int {
+	switch {
+	case a < 0:
+		return -1
+	case a > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package editor_test
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+	"github.com/rlj1202/go-BitmapLogicSimulator/editor"
+)
+
+func TestEditNot(t *testing.T) {
+	doc := editor.New(builder.New(9, 5).Image())
+
+	// wire, gate, wire
+	doc.Begin()
+	doc.Do(editor.Line{X0: 0, Y0: 2, X1: 2, Y1: 2, Conductive: true})
+	doc.Do(editor.Not{X: 4, Y: 2, Dir: gobls.DIR_RIGHT})
+	doc.Do(editor.Line{X0: 5, Y0: 2, X1: 8, Y1: 2, Conductive: true})
+	if bounds := doc.End(); bounds != image.Rect(0, 1, 9, 4) {
+		t.Errorf("bounds %v", bounds)
+	}
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(doc.Image())
+	simulator.Simulate()
+	if !simulator.Get(8, 2) {
+		t.Fatal("NOT gate output low for a low input")
+	}
+
+	// cutting the output wire leaves the end undriven
+	doc.Do(editor.Pixel{X: 7, Y: 2})
+	simulator.UpdateImage(doc.Image())
+	simulator.Set(8, 2, false)
+	simulator.Simulate()
+	if simulator.Get(8, 2) {
+		t.Error("cut wire still driven")
+	}
+
+	doc.Undo()
+	simulator.UpdateImage(doc.Image())
+	simulator.Simulate()
+	if !simulator.Get(8, 2) {
+		t.Error("undo did not reconnect the wire")
+	}
+
+	// one undo step for the whole group
+	doc.Undo()
+	if doc.CanUndo() || !doc.Matches(builder.New(9, 5).Image()) {
+		t.Error("group not undone at once")
+	}
+
+	if _, ok := doc.Redo(); !ok || doc.Image().RGBAAt(4, 1) != (color.RGBA{255, 255, 255, 255}) {
+		t.Error("redo did not restore the gate")
+	}
+	if !doc.CanRedo() {
+		t.Error("second change not redoable")
+	}
+
+	// a new change drops the redo steps
+	doc.Do(editor.Pixel{X: 0, Y: 0, Conductive: true})
+	if doc.CanRedo() {
+		t.Error("redo kept after a new change")
+	}
+}
+
+func TestEditKeepsColors(t *testing.T) {
+	img := builder.New(3, 1).Image()
+	img.Palette = append(img.Palette, color.RGBA{255, 0, 0, 255})
+	img.Pix[1] = 2
+
+	doc := editor.New(img)
+	if bounds := doc.Do(editor.Line{X0: 0, Y0: 0, X1: 2, Y1: 0, Conductive: true}); bounds != image.Rect(0, 0, 3, 1) {
+		t.Errorf("bounds %v", bounds)
+	}
+	if doc.Image().RGBAAt(1, 0) != (color.RGBA{255, 0, 0, 255}) {
+		t.Error("conductive color painted over")
+	}
+
+	if bounds := doc.Do(editor.Pixel{X: 1, Y: 0, Conductive: true}); !bounds.Empty() {
+		t.Error("no-op recorded")
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	doc := editor.New(builder.New(4, 4).Image())
+	doc.FileName = filepath.Join(dir, "circuit.png")
+	doc.Do(editor.Line{X0: 0, Y0: 0, X1: 3, Y1: 3, Conductive: true})
+	if !doc.Modified() {
+		t.Error("not modified after an edit")
+	}
+
+	err = doc.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Modified() {
+		t.Error("modified after saving")
+	}
+
+	doc.Undo()
+	if !doc.Modified() {
+		t.Error("not modified after undoing past the save")
+	}
+	doc.Redo()
+	if doc.Modified() {
+		t.Error("modified after redoing to the saved state")
+	}
+
+	opened, err := editor.Open(doc.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opened.Matches(doc.Image()) {
+		t.Error("saved file differs")
+	}
+
+	// saved through a temporary file renamed over the circuit
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "circuit.png" {
+		t.Errorf("%d files left after saving", len(files))
+	}
+
+	doc.FileName = filepath.Join(dir, "missing", "circuit.png")
+	if doc.Save() == nil {
+		t.Error("saved into a missing directory")
+	}
+}
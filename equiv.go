@@ -0,0 +1,365 @@
+package gobls
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"sort"
+)
+
+const (
+	EQUIV_EXHAUSTIVE_INPUTS = 16 // inputs up to which every assignment is simulated
+	EQUIV_RANDOM_ROUNDS     = 64 // rounds of 64 random assignments tried before the SAT solver
+
+	EQUIV_STRUCTURAL = "structural" // outputs reduce to the same logic
+	EQUIV_EXHAUSTIVE = "exhaustive"
+	EQUIV_RANDOM     = "random"
+	EQUIV_SAT        = "sat"
+)
+
+// Equivalence is the result of comparing the combinational logic of two
+// circuits.
+type Equivalence struct {
+	Equal  bool
+	Method string // how the result was found
+
+	// counterexample when not equal
+	Inputs   []bool // one per input pin
+	OutputsA []bool // one per output pin
+	OutputsB []bool
+}
+
+// CheckEquivalence compares two circuits whose pins are at the same
+// positions. Output nets are expressed as logic of the input nets: a net
+// driven by gates and buffers is the OR of their outputs, an undriven net
+// under an input pin is a free variable and any other undriven net is low.
+// The outputs must not depend on feedback loops.
+//
+// Up to EQUIV_EXHAUSTIVE_INPUTS inputs every assignment is simulated.
+// Otherwise random assignments are tried first and a SAT solver decides.
+func CheckEquivalence(a, b *Simulator, inputs, outputs Bus) (*Equivalence, error) {
+	l := newLogic(len(inputs))
+
+	outA, err := l.outputs(a, inputs, outputs)
+	if err != nil {
+		return nil, fmt.Errorf("first circuit: %v", err)
+	}
+	outB, err := l.outputs(b, inputs, outputs)
+	if err != nil {
+		return nil, fmt.Errorf("second circuit: %v", err)
+	}
+
+	result := &Equivalence{Equal: true, Method: EQUIV_STRUCTURAL}
+
+	differing := make([]int, 0)
+	for i := range outputs {
+		if outA[i] != outB[i] {
+			differing = append(differing, i)
+		}
+	}
+	if len(differing) == 0 {
+		return result, nil
+	}
+
+	counterexample := func(words []uint64, mask uint64) {
+		bit := bits.TrailingZeros64(mask)
+		values := l.evaluate(words)
+
+		result.Equal = false
+		result.Inputs = make([]bool, len(inputs))
+		for i := range inputs {
+			result.Inputs[i] = words[i]>>bit&1 != 0
+		}
+		result.OutputsA = make([]bool, len(outputs))
+		result.OutputsB = make([]bool, len(outputs))
+		for i := range outputs {
+			result.OutputsA[i] = values[outA[i]]>>bit&1 != 0
+			result.OutputsB[i] = values[outB[i]]>>bit&1 != 0
+		}
+	}
+
+	// difference of the outputs for 64 assignments at once
+	differ := func(words []uint64) uint64 {
+		values := l.evaluate(words)
+
+		mask := uint64(0)
+		for _, i := range differing {
+			mask |= values[outA[i]] ^ values[outB[i]]
+		}
+		return mask
+	}
+
+	words := make([]uint64, len(inputs))
+
+	if len(inputs) <= EQUIV_EXHAUSTIVE_INPUTS {
+		result.Method = EQUIV_EXHAUSTIVE
+
+		count := uint64(1) << uint(len(inputs))
+		for base := uint64(0); base < count; base += 64 {
+			for i := range words {
+				words[i] = 0
+				for j := uint64(0); j < 64; j++ {
+					words[i] |= ((base + j) >> uint(i) & 1) << j
+				}
+			}
+
+			mask := differ(words)
+			if count-base < 64 {
+				mask &= 1<<(count-base) - 1
+			}
+			if mask != 0 {
+				counterexample(words, mask)
+				return result, nil
+			}
+		}
+
+		return result, nil
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for round := 0; round < EQUIV_RANDOM_ROUNDS; round++ {
+		for i := range words {
+			words[i] = random.Uint64()
+		}
+
+		if mask := differ(words); mask != 0 {
+			result.Method = EQUIV_RANDOM
+			counterexample(words, mask)
+			return result, nil
+		}
+	}
+
+	result.Method = EQUIV_SAT
+	model, ok := l.miter(outA, outB, differing)
+	if !ok {
+		return result, nil
+	}
+
+	for i := range words {
+		words[i] = 0
+		if model[i] {
+			words[i] = 1
+		}
+	}
+	counterexample(words, 1)
+
+	return result, nil
+}
+
+const (
+	logicFalse = iota
+	logicInput
+	logicNot
+	logicOr
+)
+
+type logicNode struct {
+	op   int
+	args []int // input index for logicInput
+}
+
+// logic is a structurally hashed graph of NOT and OR nodes shared by the
+// compared circuits. Node 0 is constant false, nodes 1 to the number of
+// inputs are the inputs, every node comes after its arguments.
+type logic struct {
+	nodes  []logicNode
+	hashes map[string]int
+}
+
+func newLogic(inputs int) *logic {
+	l := new(logic)
+	l.hashes = make(map[string]int)
+	l.nodes = append(l.nodes, logicNode{op: logicFalse})
+	for i := 0; i < inputs; i++ {
+		l.nodes = append(l.nodes, logicNode{logicInput, []int{i}})
+	}
+
+	return l
+}
+
+func (l *logic) add(op int, args []int) int {
+	key := fmt.Sprint(op, args)
+	if node, ok := l.hashes[key]; ok {
+		return node
+	}
+
+	l.nodes = append(l.nodes, logicNode{op, args})
+	l.hashes[key] = len(l.nodes) - 1
+
+	return len(l.nodes) - 1
+}
+
+func (l *logic) not(a int) int {
+	if l.nodes[a].op == logicNot {
+		return l.nodes[a].args[0]
+	}
+	return l.add(logicNot, []int{a})
+}
+
+func (l *logic) or(args []int) int {
+	sort.Ints(args)
+
+	unique := make([]int, 0, len(args))
+	for i, arg := range args {
+		if arg == 0 || (i > 0 && arg == args[i-1]) {
+			continue
+		}
+		unique = append(unique, arg)
+	}
+
+	switch len(unique) {
+	case 0:
+		return 0
+	case 1:
+		return unique[0]
+	}
+	return l.add(logicOr, unique)
+}
+
+// outputs adds the logic of a circuit's output nets and returns their nodes.
+func (l *logic) outputs(simulator *Simulator, inputs, outputs Bus) ([]int, error) {
+	inputNets := make(map[int]int)
+	for i, pin := range inputs {
+		wire := simulator.wireAt(pin.X, pin.Y)
+		if wire < 0 {
+			return nil, fmt.Errorf("input pin %d,%d is not on a wire", pin.X, pin.Y)
+		}
+		if _, ok := inputNets[wire]; !ok {
+			inputNets[wire] = i
+		}
+	}
+
+	drivers := make(map[int][]*gate)
+	for _, g := range simulator.gates {
+		drivers[g.outIdx] = append(drivers[g.outIdx], g)
+	}
+
+	nodes := make(map[int]int)
+	visiting := make(map[int]bool)
+
+	var net func(wire int) (int, error)
+	net = func(wire int) (int, error) {
+		if node, ok := nodes[wire]; ok {
+			return node, nil
+		}
+		if visiting[wire] {
+			g := drivers[wire][0]
+			return 0, fmt.Errorf("feedback loop through the gate at %d,%d", g.center().x, g.center().y)
+		}
+
+		node := 0
+		if gates := drivers[wire]; len(gates) > 0 {
+			visiting[wire] = true
+
+			args := make([]int, 0, len(gates))
+			for _, g := range gates {
+				in, err := net(g.inIdx)
+				if err != nil {
+					return 0, err
+				}
+				if g.buffer {
+					args = append(args, in)
+				} else {
+					args = append(args, l.not(in))
+				}
+			}
+			node = l.or(args)
+
+			visiting[wire] = false
+		} else if input, ok := inputNets[wire]; ok {
+			node = 1 + input
+		}
+
+		nodes[wire] = node
+		return node, nil
+	}
+
+	result := make([]int, len(outputs))
+	for i, pin := range outputs {
+		wire := simulator.wireAt(pin.X, pin.Y)
+		if wire < 0 {
+			return nil, fmt.Errorf("output pin %d,%d is not on a wire", pin.X, pin.Y)
+		}
+
+		node, err := net(wire)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = node
+	}
+
+	return result, nil
+}
+
+// evaluate computes every node for 64 input assignments at once.
+func (l *logic) evaluate(inputs []uint64) []uint64 {
+	values := make([]uint64, len(l.nodes))
+
+	for i, node := range l.nodes {
+		switch node.op {
+		case logicInput:
+			values[i] = inputs[node.args[0]]
+		case logicNot:
+			values[i] = ^values[node.args[0]]
+		case logicOr:
+			for _, arg := range node.args {
+				values[i] |= values[arg]
+			}
+		}
+	}
+
+	return values
+}
+
+// miter asks the SAT solver for an input assignment under which one of the
+// differing output pairs differs. It returns the input values and whether
+// such an assignment exists.
+func (l *logic) miter(outA, outB []int, differing []int) ([]bool, bool) {
+	// a variable per node, then one per output pair
+	solver := newSatSolver(len(l.nodes) + len(differing))
+
+	solver.addClause(satLit(0, true))
+	for i, node := range l.nodes {
+		switch node.op {
+		case logicNot:
+			a := node.args[0]
+			solver.addClause(satLit(i, false), satLit(a, false))
+			solver.addClause(satLit(i, true), satLit(a, true))
+		case logicOr:
+			clause := []int{satLit(i, true)}
+			for _, arg := range node.args {
+				clause = append(clause, satLit(arg, false))
+				solver.addClause(satLit(i, false), satLit(arg, true))
+			}
+			solver.addClause(clause...)
+		}
+	}
+
+	either := make([]int, 0, len(differing))
+	for k, i := range differing {
+		x := len(l.nodes) + k
+		a, b := outA[i], outB[i]
+
+		// x = a xor b
+		solver.addClause(satLit(x, true), satLit(a, false), satLit(b, false))
+		solver.addClause(satLit(x, true), satLit(a, true), satLit(b, true))
+		solver.addClause(satLit(x, false), satLit(a, true), satLit(b, false))
+		solver.addClause(satLit(x, false), satLit(a, false), satLit(b, true))
+
+		either = append(either, satLit(x, false))
+	}
+	solver.addClause(either...)
+
+	if !solver.solve() {
+		return nil, false
+	}
+
+	inputs := make([]bool, 0)
+	for i, node := range l.nodes {
+		if node.op == logicInput {
+			inputs = append(inputs, solver.values[i] == 1)
+		}
+	}
+
+	return inputs, true
+}
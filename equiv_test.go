@@ -0,0 +1,156 @@
+package gobls_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func loadBuilder(b *builder.Builder) *gobls.Simulator {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(b.Image())
+	return simulator
+}
+
+func TestEquivalenceExhaustive(t *testing.T) {
+	inputs := gobls.Bus{{X: 0, Y: 2}, {X: 0, Y: 6}}
+	outputs := gobls.Bus{{X: 30, Y: 4}}
+
+	// and gate
+	and := func(x int) *builder.Builder {
+		b := builder.New(32, 10)
+		ports := b.And(x, 0, gobls.DIR_RIGHT)
+		b.Wire(0, 2, ports.In[0].X, ports.In[0].Y)
+		b.Wire(0, 6, ports.In[1].X, ports.In[1].Y)
+		b.Wire(ports.Out.X, ports.Out.Y, 30, 4)
+		return b
+	}
+
+	// first input through two inverters
+	buffer := builder.New(32, 10)
+	buffer.Not(2, 2, gobls.DIR_RIGHT)
+	buffer.Not(5, 2, gobls.DIR_RIGHT)
+	buffer.Wire(0, 2, 1, 2)
+	buffer.Wire(6, 2, 30, 2)
+	buffer.Wire(30, 2, 30, 4)
+	buffer.Wire(0, 6, 1, 6)
+
+	result, err := gobls.CheckEquivalence(loadBuilder(and(2)), loadBuilder(and(12)), inputs, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal {
+		t.Errorf("moved and gate differs: %+v", result)
+	}
+
+	result, err = gobls.CheckEquivalence(loadBuilder(and(2)), loadBuilder(buffer), inputs, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Equal || result.Method != gobls.EQUIV_EXHAUSTIVE {
+		t.Fatalf("and gate and buffer: %+v", result)
+	}
+	if !reflect.DeepEqual(result.Inputs, []bool{true, false}) ||
+		!reflect.DeepEqual(result.OutputsA, []bool{false}) ||
+		!reflect.DeepEqual(result.OutputsB, []bool{true}) {
+		t.Errorf("counterexample %+v", result)
+	}
+}
+
+// wideAnd draws an AND of 20 inputs at the top row as two groups of ten
+// when split, as one otherwise. The output is at 100,16.
+func wideAnd(split bool) (*builder.Builder, gobls.Bus, gobls.Bus) {
+	b := builder.New(104, 20)
+
+	inputs := gobls.Bus{}
+	for i := 0; i < 20; i++ {
+		x := 2 + 4*i
+		if i >= 10 {
+			x = 50 + 4*(i-10)
+		}
+		b.Set(x, 1, true)
+		inputs = append(inputs, gobls.Pin{X: x, Y: 1})
+
+		if split && i >= 10 {
+			b.Wire(x, 1, x, 8)
+			b.Not(x, 10, gobls.DIR_DOWN)
+		} else {
+			b.Not(x, 3, gobls.DIR_DOWN)
+		}
+	}
+
+	if split {
+		b.Wire(2, 5, 40, 5)
+		b.Not(42, 5, gobls.DIR_RIGHT)
+		b.Not(45, 5, gobls.DIR_RIGHT)
+		b.Wire(46, 5, 47, 16)
+
+		b.Wire(50, 12, 88, 12)
+		b.Not(90, 12, gobls.DIR_RIGHT)
+		b.Not(93, 12, gobls.DIR_RIGHT)
+		b.Wire(94, 12, 96, 16)
+
+		b.Wire(47, 16, 97, 16)
+		b.Not(98, 16, gobls.DIR_RIGHT)
+		b.Wire(99, 16, 100, 16)
+	} else {
+		b.Wire(2, 5, 88, 5)
+		b.Not(90, 5, gobls.DIR_RIGHT)
+		b.Wire(91, 5, 100, 16)
+	}
+
+	return b, inputs, gobls.Bus{{X: 100, Y: 16}}
+}
+
+func TestEquivalenceSAT(t *testing.T) {
+	flat, inputs, outputs := wideAnd(false)
+	split, _, _ := wideAnd(true)
+
+	result, err := gobls.CheckEquivalence(loadBuilder(flat), loadBuilder(split), inputs, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal || result.Method != gobls.EQUIV_SAT {
+		t.Errorf("flat and split and gates: %+v", result)
+	}
+
+	// only the pins, the output stays low
+	pins := builder.New(104, 20)
+	for _, pin := range append(inputs, outputs...) {
+		pins.Set(pin.X, pin.Y, true)
+	}
+
+	result, err = gobls.CheckEquivalence(loadBuilder(flat), loadBuilder(pins), inputs, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Equal || result.Method != gobls.EQUIV_SAT {
+		t.Fatalf("and gate and constant: %+v", result)
+	}
+	for i, value := range result.Inputs {
+		if !value {
+			t.Errorf("input %d is low in the counterexample", i)
+		}
+	}
+	if !result.OutputsA[0] || result.OutputsB[0] {
+		t.Errorf("outputs %v %v", result.OutputsA, result.OutputsB)
+	}
+}
+
+func TestEquivalenceFeedback(t *testing.T) {
+	b := builder.New(16, 8)
+	b.Not(3, 2, gobls.DIR_RIGHT)
+	b.Not(6, 2, gobls.DIR_RIGHT)
+	b.Not(9, 2, gobls.DIR_RIGHT)
+	b.Wire(10, 2, 12, 5)
+	b.Wire(12, 5, 2, 5)
+	b.Wire(2, 5, 2, 2)
+
+	simulator := loadBuilder(b)
+	_, err := gobls.CheckEquivalence(simulator, simulator, gobls.Bus{}, gobls.Bus{{X: 12, Y: 5}})
+	if err == nil {
+		t.Error("no error for a ring oscillator")
+	}
+}
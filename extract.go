@@ -0,0 +1,114 @@
+package gobls
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+var sideNames = [...]string{"top", "right", "bottom", "left"}
+
+// ExtractModule cuts a rectangle out of the loaded image. Every net of the
+// cut out circuit that continues outside the rectangle becomes a boundary
+// pin, named after its side and numbered left to right or top to bottom:
+// top0, right0, bottom1 and so on. A net leaving the rectangle at several
+// places gets one pin, on the first of the top, right, bottom and left sides
+// it leaves through. Pin groups of the manifest lying entirely inside the
+// rectangle are kept. The manifest is optional.
+//
+// Placed at the rectangle's corner in a layout, the module reproduces the
+// original circuit. Gates and crossings cut by the rectangle are an error.
+func (simulator *Simulator) ExtractModule(rect image.Rectangle, manifest *Manifest) (*image.RGBA, *Manifest, error) {
+	rect = rect.Intersect(image.Rect(0, 0, simulator.width, simulator.height))
+	if rect.Empty() {
+		return nil, nil, fmt.Errorf("rectangle outside the %dx%d image", simulator.width, simulator.height)
+	}
+
+	// gates and crossings must lie on one side of the border
+	centers := make([]point, 0, len(simulator.gates)+len(simulator.crossings))
+	for _, g := range simulator.gates {
+		centers = append(centers, g.center())
+	}
+	centers = append(centers, simulator.crossings...)
+	for _, center := range centers {
+		inside := 0
+		for _, p := range blockPixels(center) {
+			if (image.Point{p.X, p.Y}).In(rect) {
+				inside++
+			}
+		}
+		if inside > 0 && inside < 9 {
+			return nil, nil, fmt.Errorf("rectangle cuts the gate or crossing at %d,%d", center.x, center.y)
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(img, img.Rect, simulator.curImage, rect.Min, draw.Src)
+
+	// nets of the module alone, two of them may be one net outside
+	module := NewSimulator()
+	module.LoadImage(img)
+
+	pins := NewManifest()
+	seen := make(map[int]bool)
+	count := [4]int{}
+
+	exits := func(x, y, dx, dy int) {
+		if simulator.wireAt(x, y) < 0 || simulator.wireAt(x+dx, y+dy) < 0 {
+			return
+		}
+
+		p := Pin{x - rect.Min.X, y - rect.Min.Y}
+		wire := module.wireAt(p.X, p.Y)
+		if seen[wire] {
+			return
+		}
+		seen[wire] = true
+
+		side := DIR_UP
+		switch {
+		case dx > 0:
+			side = DIR_RIGHT
+		case dy > 0:
+			side = DIR_DOWN
+		case dx < 0:
+			side = DIR_LEFT
+		}
+
+		pins.Pins[fmt.Sprintf("%s%d", sideNames[side], count[side])] = Bus{p}
+		count[side]++
+	}
+
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		exits(x, rect.Min.Y, 0, -1)
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		exits(rect.Max.X-1, y, 1, 0)
+	}
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		exits(x, rect.Max.Y-1, 0, 1)
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		exits(rect.Min.X, y, -1, 0)
+	}
+
+	if manifest != nil {
+	groups:
+		for name, bus := range manifest.Pins {
+			if _, ok := pins.Pins[name]; ok {
+				continue
+			}
+
+			moved := make(Bus, len(bus))
+			for i, pin := range bus {
+				if !(image.Point{pin.X, pin.Y}).In(rect) {
+					continue groups
+				}
+				moved[i] = Pin{pin.X - rect.Min.X, pin.Y - rect.Min.Y}
+			}
+			pins.Pins[name] = moved
+		}
+	}
+
+	return img, pins, nil
+}
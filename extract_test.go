@@ -0,0 +1,55 @@
+package gobls_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestExtractModule(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(
+		"............",
+		"....##......",
+		"#####.######",
+		"....##......",
+		"..#.........",
+	))
+
+	manifest := gobls.NewManifest()
+	manifest.Pins["probe"] = gobls.Bus{{3, 2}}
+	manifest.Pins["out"] = gobls.Bus{{11, 2}}
+
+	img, pins, err := simulator.ExtractModule(image.Rect(2, 0, 9, 5), manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.Rect.Dx() != 7 || img.Rect.Dy() != 5 {
+		t.Fatalf("module size %v", img.Rect)
+	}
+
+	want := map[string]gobls.Pin{"left0": {0, 2}, "right0": {6, 2}, "probe": {1, 2}}
+	if len(pins.Pins) != len(want) {
+		t.Errorf("pins %v", pins.Pins)
+	}
+	for name, pin := range want {
+		if bus := pins.Pins[name]; len(bus) != 1 || bus[0] != pin {
+			t.Errorf("pin %s = %v, want %v", name, bus, pin)
+		}
+	}
+
+	// the module is the NOT gate
+	module := gobls.NewSimulator()
+	module.LoadImage(img)
+	module.Simulate()
+	if !module.Get(6, 2) {
+		t.Error("module output low for a low input")
+	}
+
+	_, _, err = simulator.ExtractModule(image.Rect(5, 0, 9, 5), nil)
+	if err == nil {
+		t.Error("no error for a cut gate")
+	}
+}
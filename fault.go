@@ -0,0 +1,270 @@
+package gobls
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Fault holds a net or a gate output at a fixed value.
+type Fault struct {
+	X, Y  int  // a pixel of the net, or the center of the gate
+	Gate  bool // only the output of the gate, not the other drivers of its net
+	Value bool // stuck-at value
+}
+
+func (fault Fault) String() string {
+	value := 0
+	if fault.Value {
+		value = 1
+	}
+
+	if fault.Gate {
+		return fmt.Sprintf("gate %d,%d stuck-at-%d", fault.X, fault.Y, value)
+	}
+	return fmt.Sprintf("net %d,%d stuck-at-%d", fault.X, fault.Y, value)
+}
+
+// InjectFault holds a net or gate output at the fault's value until the
+// faults are cleared or another image is loaded. Set has no effect on a
+// faulty net.
+func (simulator *Simulator) InjectFault(fault Fault) error {
+	if fault.Gate {
+		for i, g := range simulator.gates {
+			center := g.center()
+			if center.x == fault.X && center.y == fault.Y {
+				if simulator.gateFaults == nil {
+					simulator.gateFaults = make(map[int]bool)
+				}
+				simulator.gateFaults[i] = fault.Value
+				simulator.applyFaults()
+				return nil
+			}
+		}
+
+		return fmt.Errorf("no gate at %d,%d", fault.X, fault.Y)
+	}
+
+	wire := simulator.wireAt(fault.X, fault.Y)
+	if wire < 0 {
+		return fmt.Errorf("no net at %d,%d", fault.X, fault.Y)
+	}
+
+	if simulator.netFaults == nil {
+		simulator.netFaults = make(map[int]bool)
+	}
+	simulator.netFaults[wire] = fault.Value
+	simulator.applyFaults()
+
+	return nil
+}
+
+func (simulator *Simulator) ClearFaults() {
+	simulator.netFaults = nil
+	simulator.gateFaults = nil
+}
+
+// applyFaults overrides the gate and net states of the injected faults.
+func (simulator *Simulator) applyFaults() {
+	for i, value := range simulator.gateFaults {
+		simulator.gates[i].setState(value)
+	}
+
+	simulator.storeGateStatesToWires()
+}
+
+// Reset clears every net and gate state as if the image had just been
+// loaded. Injected faults and attached devices are kept.
+func (simulator *Simulator) Reset() {
+	for i := range simulator.states {
+		simulator.states[i] = false
+	}
+	for _, g := range simulator.gates {
+		g.setState(false)
+	}
+	simulator.steps = 0
+
+	simulator.simulateGates()
+}
+
+// FaultList returns stuck-at-0 and stuck-at-1 faults for every net and for
+// the outputs of gates sharing their net with other gates. A gate driving a
+// net alone is covered by the net's faults.
+func (simulator *Simulator) FaultList() []Fault {
+	faults := make([]Fault, 0)
+
+	seen := make(map[int]bool)
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.wireAt(x, y)
+			if wire < 0 || seen[wire] {
+				continue
+			}
+			seen[wire] = true
+
+			faults = append(faults, Fault{X: x, Y: y}, Fault{X: x, Y: y, Value: true})
+		}
+	}
+
+	drivers := make(map[int]int)
+	for _, g := range simulator.gates {
+		drivers[g.outIdx]++
+	}
+
+	gateFaults := make([]Fault, 0)
+	for _, g := range simulator.gates {
+		if drivers[g.outIdx] < 2 {
+			continue
+		}
+
+		center := g.center()
+		gateFaults = append(gateFaults,
+			Fault{X: center.x, Y: center.y, Gate: true},
+			Fault{X: center.x, Y: center.y, Gate: true, Value: true})
+	}
+	sort.SliceStable(gateFaults, func(i, j int) bool {
+		return pinLess(Pin{gateFaults[i].X, gateFaults[i].Y}, Pin{gateFaults[j].X, gateFaults[j].Y})
+	})
+
+	return append(faults, gateFaults...)
+}
+
+// TestSet is a sequence of input assignments applied to named pin groups
+// of a manifest. Each vector is applied and then simulated for Steps steps
+// before the Outputs pin groups are read; state carries over from one
+// vector to the next.
+type TestSet struct {
+	Steps   int
+	Outputs []string
+	Vectors []map[string]uint64 // pin group -> value, first pin is the lowest bit
+}
+
+type FaultResult struct {
+	Fault    Fault
+	Detected bool
+	Vector   int // first vector with a different output, -1 when undetected
+}
+
+type Coverage struct {
+	Results  []FaultResult
+	Detected int
+}
+
+// Ratio returns the detected fraction of the faults.
+func (coverage *Coverage) Ratio() float64 {
+	if len(coverage.Results) == 0 {
+		return 0
+	}
+	return float64(coverage.Detected) / float64(len(coverage.Results))
+}
+
+// FaultCoverage runs the test set on the fault free circuit and then once
+// per fault, and reports the faults whose outputs differ from the fault free
+// responses. The devices of the manifest are attached once, replacing the
+// attached devices, and every one must be a Resetter: the simulator and the
+// devices are reset before each run so memory contents do not carry over
+// from one run to the next. Devices doing external I/O, such as a uart, can
+// not be replayed and are rejected. The fault free run is repeated last so
+// displays write its image on close. The simulator is left without faults
+// and devices.
+func (simulator *Simulator) FaultCoverage(manifest *Manifest, tests *TestSet, faults []Fault) (*Coverage, error) {
+	outputs := make([]Bus, len(tests.Outputs))
+	for i, name := range tests.Outputs {
+		bus, err := manifest.Bus(name)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = bus
+	}
+
+	inputs := make([]map[string]Bus, len(tests.Vectors))
+	for i, vector := range tests.Vectors {
+		inputs[i] = make(map[string]Bus)
+		for name := range vector {
+			bus, err := manifest.Bus(name)
+			if err != nil {
+				return nil, err
+			}
+			inputs[i][name] = bus
+		}
+	}
+
+	err := simulator.DetachAll()
+	if err != nil {
+		return nil, err
+	}
+	err = manifest.Attach(simulator)
+	if err != nil {
+		simulator.DetachAll()
+		return nil, err
+	}
+	for _, device := range simulator.Devices() {
+		if _, ok := device.(Resetter); !ok {
+			simulator.DetachAll()
+			return nil, fmt.Errorf("%T can not be reset for every fault, devices doing external I/O are not supported", device)
+		}
+	}
+
+	// responses of every vector, one value per output
+	run := func() [][]uint64 {
+		simulator.Reset()
+		for _, device := range simulator.Devices() {
+			device.(Resetter).Reset()
+		}
+
+		responses := make([][]uint64, len(tests.Vectors))
+		for i, vector := range tests.Vectors {
+			for name, value := range vector {
+				simulator.WriteBus(inputs[i][name], value)
+			}
+			for step := 0; step < tests.Steps; step++ {
+				simulator.Simulate()
+			}
+
+			responses[i] = make([]uint64, len(outputs))
+			for j, bus := range outputs {
+				responses[i][j] = simulator.ReadBus(bus)
+			}
+		}
+
+		return responses
+	}
+
+	simulator.ClearFaults()
+	good := run()
+
+	coverage := new(Coverage)
+	for _, fault := range faults {
+		err := simulator.InjectFault(fault)
+		if err != nil {
+			simulator.ClearFaults()
+			simulator.DetachAll()
+			return nil, err
+		}
+
+		responses := run()
+
+		result := FaultResult{Fault: fault, Vector: -1}
+		for i, response := range responses {
+			for j := range response {
+				if response[j] != good[i][j] {
+					result.Detected = true
+				}
+			}
+			if result.Detected {
+				result.Vector = i
+				break
+			}
+		}
+		if result.Detected {
+			coverage.Detected++
+		}
+		coverage.Results = append(coverage.Results, result)
+
+		simulator.ClearFaults()
+	}
+
+	run()
+	simulator.Reset()
+
+	return coverage, simulator.DetachAll()
+}
@@ -0,0 +1,159 @@
+package gobls_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func TestFaultCoverage(t *testing.T) {
+	b := builder.New(32, 10)
+	ports := b.And(2, 0, gobls.DIR_RIGHT)
+	b.Pin("a", 0, 2)
+	b.Pin("b", 0, 6)
+	b.Pin("out", 30, 4)
+	b.Wire(0, 2, ports.In[0].X, ports.In[0].Y)
+	b.Wire(0, 6, ports.In[1].X, ports.In[1].Y)
+	b.Wire(ports.Out.X, ports.Out.Y, 30, 4)
+
+	simulator := loadBuilder(b)
+	faults := simulator.FaultList()
+
+	tests := &gobls.TestSet{
+		Steps:   20,
+		Outputs: []string{"out"},
+		Vectors: []map[string]uint64{
+			{"a": 0, "b": 0},
+			{"a": 1, "b": 0},
+			{"a": 0, "b": 1},
+			{"a": 1, "b": 1},
+		},
+	}
+
+	coverage, err := simulator.FaultCoverage(b.Manifest(), tests, faults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coverage.Detected != len(faults) {
+		for _, result := range coverage.Results {
+			if !result.Detected {
+				t.Errorf("%v not detected by the truth table", result.Fault)
+			}
+		}
+	}
+
+	tests.Vectors = tests.Vectors[3:]
+	coverage, err = simulator.FaultCoverage(b.Manifest(), tests, faults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range coverage.Results {
+		if result.Fault == (gobls.Fault{X: 30, Y: 4, Value: true}) {
+			t.Errorf("%v detected with only the output high", result.Fault)
+		}
+	}
+	if coverage.Ratio() >= 1 {
+		t.Errorf("coverage %v with a single vector", coverage.Ratio())
+	}
+}
+
+func TestFaultCoverageFreshDevices(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(
+		"#......",
+		".......",
+		"###....",
+		".......",
+		"###....",
+		".......",
+		"#......",
+	))
+
+	// vector 0 reads the zeroed RAM, vector 1 writes a one into it
+	manifest := gobls.NewManifest()
+	manifest.Pins["a"] = gobls.Bus{{0, 0}}
+	manifest.Pins["d"] = gobls.Bus{{0, 2}}
+	manifest.Pins["we"] = gobls.Bus{{0, 6}}
+	manifest.Devices = []json.RawMessage{json.RawMessage(`{"Type": "ram", "Address": "a", "Data": "d", "WriteEnable": "we"}`)}
+
+	tests := &gobls.TestSet{
+		Steps:   2,
+		Outputs: []string{"d"},
+		Vectors: []map[string]uint64{
+			{"we": 0},
+			{"we": 1, "d": 1},
+		},
+	}
+
+	faults := []gobls.Fault{{X: 0, Y: 4, Value: true}, {X: 0, Y: 2, Value: true}}
+	coverage, err := simulator.FaultCoverage(manifest, tests, faults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the one written by the previous run must not be read back
+	if coverage.Results[0].Detected {
+		t.Error("fault on an unused net detected")
+	}
+	if !coverage.Results[1].Detected || coverage.Results[1].Vector != 0 {
+		t.Errorf("stuck data %+v", coverage.Results[1])
+	}
+	if len(simulator.Devices()) != 0 {
+		t.Errorf("%d devices left attached", len(simulator.Devices()))
+	}
+}
+
+func TestFaultCoverageRejectsUART(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage("#.#"))
+
+	manifest := gobls.NewManifest()
+	manifest.Pins["tx"] = gobls.Bus{{0, 0}}
+	manifest.Pins["rx"] = gobls.Bus{{2, 0}}
+	manifest.Devices = []json.RawMessage{json.RawMessage(`{"Type": "uart", "TX": "tx", "RX": "rx", "Period": 8}`)}
+
+	tests := &gobls.TestSet{Steps: 1, Outputs: []string{"tx"}, Vectors: []map[string]uint64{{}}}
+	_, err := simulator.FaultCoverage(manifest, tests, []gobls.Fault{{X: 0, Y: 0, Value: true}})
+	if err == nil {
+		t.Error("uart accepted")
+	}
+	if len(simulator.Devices()) != 0 {
+		t.Errorf("%d devices left attached", len(simulator.Devices()))
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	b := builder.New(8, 3)
+	b.Wire(0, 1, 1, 1)
+	b.Not(3, 1, gobls.DIR_RIGHT)
+	b.Wire(5, 1, 7, 1)
+
+	simulator := loadBuilder(b)
+	err := simulator.InjectFault(gobls.Fault{X: 0, Y: 1, Value: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	simulator.Set(0, 1, false)
+	for i := 0; i < 10; i++ {
+		simulator.Simulate()
+	}
+	if !simulator.Get(0, 1) || simulator.Get(7, 1) {
+		t.Error("stuck-at-1 input was overridden")
+	}
+
+	simulator.ClearFaults()
+	simulator.Set(0, 1, false)
+	for i := 0; i < 10; i++ {
+		simulator.Simulate()
+	}
+	if !simulator.Get(7, 1) {
+		t.Error("gate output low after clearing the fault")
+	}
+
+	if err := simulator.InjectFault(gobls.Fault{X: 3, Y: 0, Gate: true}); err == nil {
+		t.Error("no error for a fault on a missing gate")
+	}
+}
@@ -0,0 +1,115 @@
+package gobls
+
+// force is the value of a forced pixel. When an edit joins nets forced to
+// different values, the force applied last wins.
+type force struct {
+	value bool
+	seq   int
+}
+
+// Force holds the net under a pixel at a value regardless of its drivers
+// until it is released. Forces are kept by pixel and survive loading another
+// image as long as the pixel is still conductive; of the forces ending up on
+// one net the last applied holds it. It returns false for insulation.
+func (simulator *Simulator) Force(x, y int, value bool) bool {
+	wire := simulator.wireAt(x, y)
+	if wire < 0 {
+		return false
+	}
+
+	simulator.releaseNet(wire)
+	if simulator.forces == nil {
+		simulator.forces = make(map[Pin]force)
+	}
+	simulator.forceSeq++
+	simulator.forces[Pin{x, y}] = force{value, simulator.forceSeq}
+
+	simulator.updateForcedNets()
+	simulator.storeGateStatesToWires()
+
+	return true
+}
+
+// Release lets the drivers of the net under a pixel set its value again.
+// It returns false when the net was not forced.
+func (simulator *Simulator) Release(x, y int) bool {
+	wire := simulator.wireAt(x, y)
+	if wire < 0 || !simulator.releaseNet(wire) {
+		return false
+	}
+
+	simulator.updateForcedNets()
+	simulator.storeGateStatesToWires()
+
+	return true
+}
+
+func (simulator *Simulator) ReleaseAll() {
+	simulator.forces = nil
+	simulator.forcedNets = nil
+	simulator.storeGateStatesToWires()
+}
+
+func (simulator *Simulator) IsForced(x, y int) bool {
+	wire := simulator.wireAt(x, y)
+	if wire < 0 {
+		return false
+	}
+
+	_, ok := simulator.forcedNets[wire]
+	return ok
+}
+
+// ForcedPixels returns every conductive pixel of the forced nets with the
+// value it is held at.
+func (simulator *Simulator) ForcedPixels() map[Pin]bool {
+	pixels := make(map[Pin]bool)
+	if len(simulator.forcedNets) == 0 {
+		return pixels
+	}
+
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			if value, ok := simulator.forcedNets[simulator.wireAt(x, y)]; ok {
+				pixels[Pin{x, y}] = value
+			}
+		}
+	}
+
+	return pixels
+}
+
+// releaseNet removes the forces on a net and reports whether there were any.
+func (simulator *Simulator) releaseNet(wire int) bool {
+	released := false
+	for pin := range simulator.forces {
+		if simulator.wireAt(pin.X, pin.Y) == wire {
+			delete(simulator.forces, pin)
+			released = true
+		}
+	}
+
+	return released
+}
+
+// updateForcedNets maps the forced pixels to nets of the current image.
+func (simulator *Simulator) updateForcedNets() {
+	simulator.forcedNets = nil
+
+	latest := make(map[int]int)
+	for pin, f := range simulator.forces {
+		wire := simulator.wireAt(pin.X, pin.Y)
+		if wire < 0 {
+			continue
+		}
+		if seq, ok := latest[wire]; ok && seq > f.seq {
+			continue
+		}
+		latest[wire] = f.seq
+
+		if simulator.forcedNets == nil {
+			simulator.forcedNets = make(map[int]bool)
+		}
+		simulator.forcedNets[wire] = f.value
+	}
+}
@@ -0,0 +1,82 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func TestForce(t *testing.T) {
+	// input, two inverters, output
+	b := builder.New(12, 3)
+	b.Wire(0, 1, 1, 1)
+	b.Not(3, 1, gobls.DIR_RIGHT)
+	b.Not(6, 1, gobls.DIR_RIGHT)
+	b.Wire(7, 1, 11, 1)
+
+	simulator := loadBuilder(b)
+	steps := func() {
+		for i := 0; i < 10; i++ {
+			simulator.Simulate()
+		}
+	}
+	steps()
+
+	// the net between the gates is driven high
+	if !simulator.Force(4, 1, false) {
+		t.Fatal("force failed")
+	}
+	steps()
+	if simulator.Get(4, 1) || !simulator.Get(11, 1) || !simulator.IsForced(4, 1) {
+		t.Error("forced net not held low")
+	}
+
+	simulator.Set(4, 1, true)
+	if simulator.Get(4, 1) {
+		t.Error("Set changed a forced net")
+	}
+
+	// forces survive reloading
+	simulator.LoadImage(b.Image())
+	steps()
+	if !simulator.IsForced(4, 1) || simulator.Get(4, 1) || len(simulator.ForcedPixels()) == 0 {
+		t.Error("force lost by reloading")
+	}
+
+	if !simulator.Release(4, 1) {
+		t.Fatal("release failed")
+	}
+	steps()
+	if !simulator.Get(4, 1) || simulator.Get(11, 1) || simulator.IsForced(4, 1) {
+		t.Error("released net not driven")
+	}
+	if simulator.Release(4, 1) {
+		t.Error("released a net twice")
+	}
+}
+
+func TestForceConflict(t *testing.T) {
+	// two wires forced to opposite values, then joined
+	rows := []string{
+		"###.###",
+		".......",
+	}
+
+	for _, first := range []bool{false, true} {
+		simulator := gobls.NewSimulator()
+		simulator.LoadImage(asciiImage(rows...))
+		simulator.Force(0, 0, first)
+		simulator.Force(6, 0, !first)
+
+		joined := append([]string{"#######"}, rows[1:]...)
+		for i := 0; i < 20; i++ {
+			simulator.UpdateImage(asciiImage(joined...))
+			simulator.Simulate()
+			if simulator.Get(0, 0) != !first {
+				t.Fatalf("forced %v first: joined net is %v, not the last force", first, simulator.Get(0, 0))
+			}
+			simulator.UpdateImage(asciiImage(rows...))
+		}
+	}
+}
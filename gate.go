@@ -4,6 +4,14 @@ import (
 	"math/rand"
 )
 
+// gate orientations, the direction the output points to
+const (
+	DIR_UP = iota
+	DIR_RIGHT
+	DIR_DOWN
+	DIR_LEFT
+)
+
 type point struct {
 	x, y int
 }
@@ -12,11 +20,18 @@ type gate struct {
 	state     bool
 	slowState float32
 
-	in, out       point
+	at, in, out   point // center, input and output pixels
+	dir           int
+	buffer        bool // output follows the input instead of inverting it
 	inIdx, outIdx int
 	inGates       []int
 }
 
+// center returns the insulating pixel in the middle of the gate.
+func (g *gate) center() point {
+	return g.at
+}
+
 func (g *gate) setState(newState bool) {
 	g.state = newState
 
@@ -0,0 +1,135 @@
+package gobls
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadIntelHex decodes an Intel HEX stream into a flat byte image starting at
+// address 0. Gaps are filled with zeros. Records beyond size bytes are an
+// error.
+func ReadIntelHex(r io.Reader, size int) ([]byte, error) {
+	data := make([]byte, 0)
+	base := 0
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if text[0] != ':' {
+			return nil, fmt.Errorf("line %d: record does not start with ':'", line)
+		}
+
+		record, err := hex.DecodeString(text[1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if len(record) < 5 || len(record) != int(record[0])+5 {
+			return nil, fmt.Errorf("line %d: invalid record length", line)
+		}
+
+		sum := byte(0)
+		for _, b := range record {
+			sum += b
+		}
+		if sum != 0 {
+			return nil, fmt.Errorf("line %d: checksum mismatch", line)
+		}
+
+		length := int(record[0])
+		offset := int(record[1])<<8 | int(record[2])
+		payload := record[4 : 4+length]
+
+		switch record[3] {
+		case 0x00: // data
+			address := base + offset
+			if address+length > size {
+				return nil, fmt.Errorf("line %d: record at %#x is beyond the memory of %d bytes", line, address, size)
+			}
+			if address+length > len(data) {
+				data = append(data, make([]byte, address+length-len(data))...)
+			}
+			copy(data[address:], payload)
+		case 0x01: // end of file
+			return data, nil
+		case 0x02: // extended segment address
+			if length != 2 {
+				return nil, fmt.Errorf("line %d: invalid segment address record", line)
+			}
+			base = (int(payload[0])<<8 | int(payload[1])) << 4
+		case 0x04: // extended linear address
+			if length != 2 {
+				return nil, fmt.Errorf("line %d: invalid linear address record", line)
+			}
+			base = (int(payload[0])<<8 | int(payload[1])) << 16
+		case 0x03, 0x05: // start address, not needed
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type %02x", line, record[3])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ReadMemoryFile loads at most words memory words from an Intel HEX file
+// (.hex, .ihx) or a raw binary file. Each word takes (dataWidth+7)/8 bytes,
+// little endian.
+func ReadMemoryFile(fileName string, dataWidth, words int) ([]uint64, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	size := words * wordBytes(dataWidth)
+
+	var data []byte
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".hex", ".ihx":
+		data, err = ReadIntelHex(file, size)
+	default:
+		data, err = ioutil.ReadAll(io.LimitReader(file, int64(size)+1))
+		if err == nil && len(data) > size {
+			err = fmt.Errorf("larger than the memory of %d bytes", size)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+
+	return bytesToWords(data, dataWidth), nil
+}
+
+// wordBytes returns the bytes a word of the data width takes in a file.
+func wordBytes(dataWidth int) int {
+	if dataWidth < 8 {
+		return 1
+	}
+	return (dataWidth + 7) / 8
+}
+
+func bytesToWords(data []byte, dataWidth int) []uint64 {
+	wordSize := wordBytes(dataWidth)
+
+	words := make([]uint64, (len(data)+wordSize-1)/wordSize)
+	for i, b := range data {
+		words[i/wordSize] |= uint64(b) << uint(8*(i%wordSize))
+	}
+
+	return words
+}
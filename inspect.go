@@ -0,0 +1,161 @@
+package gobls
+
+import (
+	"image"
+)
+
+// NetInfo describes a net of the loaded circuit.
+type NetInfo struct {
+	ID      int             // kept until the image is loaded again or UpdateImage changes the net
+	Bounds  image.Rectangle // bounding box of the pixels
+	Pixels  int
+	Drivers []Pin // centers of the gates driving the net
+	Readers []Pin // centers of the gates reading the net
+	State   bool
+}
+
+// GateInfo describes a gate of the loaded circuit.
+type GateInfo struct {
+	X, Y      int // center
+	Dir       int
+	Buffer    bool
+	In, Out   Pin // input and output pixels
+	InNet     int
+	OutNet    int
+	State     bool
+	SlowState float32 // output level moving towards State, from 0 to 1
+}
+
+// NetAt describes the net under a pixel. The first query after loading an
+// image measures every net and indexes the gates by net.
+func (simulator *Simulator) NetAt(x, y int) (NetInfo, bool) {
+	wire := simulator.wireAt(x, y)
+	if wire < 0 {
+		return NetInfo{}, false
+	}
+
+	if simulator.netPixels == nil {
+		simulator.measureNets()
+	}
+
+	info := NetInfo{
+		ID:      wire,
+		Bounds:  simulator.netBounds[wire],
+		Pixels:  simulator.netPixels[wire],
+		Drivers: make([]Pin, 0),
+		Readers: make([]Pin, 0),
+		State:   simulator.states[wire],
+	}
+
+	if simulator.drivers == nil {
+		simulator.drivers = simulator.netDrivers()
+		simulator.readers = simulator.netReaders()
+	}
+	for _, i := range simulator.drivers[wire] {
+		info.Drivers = append(info.Drivers, Pin{simulator.gates[i].at.x, simulator.gates[i].at.y})
+	}
+	for _, i := range simulator.readers[wire] {
+		info.Readers = append(info.Readers, Pin{simulator.gates[i].at.x, simulator.gates[i].at.y})
+	}
+
+	return info, true
+}
+
+// NetMask returns the pixels of the net under a pixel, crossings followed,
+// as a mask over the net's bounding box, nil on insulation.
+func (simulator *Simulator) NetMask(x, y int) *image.Alpha {
+	wire := simulator.wireAt(x, y)
+	if wire < 0 {
+		return nil
+	}
+
+	if simulator.netPixels == nil {
+		simulator.measureNets()
+	}
+
+	bounds := simulator.netBounds[wire]
+	mask := image.NewAlpha(bounds)
+	for my := bounds.Min.Y; my < bounds.Max.Y; my++ {
+		row := simulator.netMap[my*simulator.width : (my+1)*simulator.width]
+		for mx := bounds.Min.X; mx < bounds.Max.X; mx++ {
+			if int(row[mx]) == wire {
+				mask.Pix[mask.PixOffset(mx, my)] = 255
+			}
+		}
+	}
+
+	return mask
+}
+
+// GateAt describes the gate whose 3x3 pattern covers a pixel, the one
+// centered on it when patterns overlap, else the first in rows.
+func (simulator *Simulator) GateAt(x, y int) (GateInfo, bool) {
+	if simulator.gateCenters == nil {
+		simulator.gateCenters = make(map[point]int, len(simulator.gates))
+		for i, g := range simulator.gates {
+			simulator.gateCenters[g.at] = i
+		}
+	}
+
+	i, ok := simulator.gateCenters[point{x, y}]
+	for dy := -1; dy <= 1 && !ok; dy++ {
+		for dx := -1; dx <= 1 && !ok; dx++ {
+			i, ok = simulator.gateCenters[point{x + dx, y + dy}]
+		}
+	}
+	if !ok {
+		return GateInfo{}, false
+	}
+	found := simulator.gates[i]
+
+	return GateInfo{
+		X:         found.at.x,
+		Y:         found.at.y,
+		Dir:       found.dir,
+		Buffer:    found.buffer,
+		In:        Pin{found.in.x, found.in.y},
+		Out:       Pin{found.out.x, found.out.y},
+		InNet:     found.inIdx,
+		OutNet:    found.outIdx,
+		State:     found.state,
+		SlowState: found.slowState,
+	}, true
+}
+
+// measureNets counts the pixels and bounding box of every net.
+func (simulator *Simulator) measureNets() {
+	simulator.netPixels = make([]int, len(simulator.states))
+	simulator.netBounds = make([]image.Rectangle, len(simulator.states))
+
+	i := 0
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.netMap[i]
+			i++
+			if wire < 0 {
+				continue
+			}
+
+			simulator.measurePixel(int(wire), x, y)
+		}
+	}
+}
+
+func (simulator *Simulator) measurePixel(wire, x, y int) {
+	bounds := &simulator.netBounds[wire]
+	if simulator.netPixels[wire] == 0 {
+		*bounds = image.Rect(x, y, x+1, y+1)
+	} else {
+		if x < bounds.Min.X {
+			bounds.Min.X = x
+		} else if x >= bounds.Max.X {
+			bounds.Max.X = x + 1
+		}
+		if y < bounds.Min.Y {
+			bounds.Min.Y = y
+		} else if y >= bounds.Max.Y {
+			bounds.Max.Y = y + 1
+		}
+	}
+	simulator.netPixels[wire]++
+}
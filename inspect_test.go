@@ -0,0 +1,94 @@
+package gobls_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func TestNetAt(t *testing.T) {
+	rows := []string{
+		"..##.....",
+		"###.####.",
+		"..##.....",
+		".........",
+		"#####....",
+	}
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(rows...))
+	simulator.Simulate()
+
+	in, ok := simulator.NetAt(0, 1)
+	if !ok {
+		t.Fatal("no net at 0,1")
+	}
+	if in.Pixels != 7 || in.Bounds != image.Rect(0, 0, 4, 3) || len(in.Drivers) != 0 ||
+		len(in.Readers) != 1 || in.Readers[0] != (gobls.Pin{3, 1}) || in.State {
+		t.Errorf("input net %+v", in)
+	}
+
+	out, _ := simulator.NetAt(7, 1)
+	if out.Pixels != 4 || len(out.Drivers) != 1 || out.Drivers[0] != (gobls.Pin{3, 1}) || !out.State {
+		t.Errorf("output net %+v", out)
+	}
+
+	if _, ok := simulator.NetAt(3, 1); ok {
+		t.Error("net on insulation")
+	}
+
+	gate, ok := simulator.GateAt(2, 2)
+	if !ok || gate.X != 3 || gate.Y != 1 || gate.Dir != gobls.DIR_RIGHT || gate.InNet != in.ID || gate.OutNet != out.ID || !gate.State || gate.SlowState != 1 {
+		t.Errorf("gate %+v", gate)
+	}
+	if _, ok := simulator.GateAt(7, 4); ok {
+		t.Error("gate away from any gate")
+	}
+
+	// changing the bottom wire keeps the gate's nets and measures the new one
+	rows[4] = "########."
+	simulator.UpdateImage(asciiImage(rows...))
+
+	if again, _ := simulator.NetAt(0, 1); again.ID != in.ID {
+		t.Errorf("input net id changed from %d to %d", in.ID, again.ID)
+	}
+	if bottom, _ := simulator.NetAt(0, 4); bottom.Pixels != 8 || bottom.Bounds != image.Rect(0, 4, 8, 5) {
+		t.Errorf("bottom net %+v", bottom)
+	}
+}
+
+func TestNetMask(t *testing.T) {
+	b := builder.New(9, 7)
+	for x := 0; x < 9; x++ {
+		b.Set(x, 3, true)
+	}
+	for y := 0; y < 7; y++ {
+		b.Set(4, y, true)
+	}
+	b.Crossing(4, 3)
+	b.Set(0, 0, true)
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(b.Image())
+
+	mask := simulator.NetMask(0, 3)
+	if mask == nil || mask.Rect != image.Rect(0, 3, 9, 4) {
+		t.Fatalf("horizontal wire mask %v", mask)
+	}
+	for x := 0; x < 9; x++ {
+		if (mask.AlphaAt(x, 3).A != 0) != (x != 4) {
+			t.Errorf("pixel %d,3 masked %v", x, mask.AlphaAt(x, 3).A != 0)
+		}
+	}
+
+	vertical := simulator.NetMask(4, 0)
+	if vertical == nil || vertical.Rect != image.Rect(4, 0, 5, 7) || vertical.AlphaAt(4, 3).A != 0 || vertical.AlphaAt(4, 6).A == 0 {
+		t.Errorf("vertical wire mask %v", vertical)
+	}
+
+	if simulator.NetMask(1, 1) != nil {
+		t.Error("mask on insulation")
+	}
+}
@@ -0,0 +1,346 @@
+package gobls
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Layout composes a design from module bitmaps placed on a parent drawing.
+// Modules are stamped over the parent, insulation included, and connect to
+// it where their conductive border pixels touch parent wires:
+//
+//	{
+//		"Image": "cpu_top.png",
+//		"Modules": {
+//			"adder": {"Image": "adder.png", "Manifest": "adder.json"},
+//			"alu": {"Layout": "alu_layout.json"}
+//		},
+//		"Instances": [
+//			{"Name": "add0", "Module": "adder", "X": 10, "Y": 20},
+//			{"Name": "add1", "Module": "adder", "X": 10, "Y": 40, "Mirror": true},
+//			{"Name": "alu", "Module": "alu", "X": 80, "Y": 20, "Rotate": 1}
+//		],
+//		"Pins": {"clk": [{"X": 0, "Y": 4}]}
+//	}
+type Layout struct {
+	Image         string // parent drawing, optional
+	Width, Height int    // size without a parent drawing
+	Modules       map[string]Module
+	Instances     []Instance
+	Pins          map[string]Bus // pins of the parent drawing
+
+	fileName string
+	dir      string
+}
+
+// Module is a bitmap with an optional pin manifest, or a nested layout.
+type Module struct {
+	Image    string
+	Manifest string
+	Layout   string
+}
+
+type Instance struct {
+	Name   string
+	Module string
+	X, Y   int  // top left corner of the placed module
+	Rotate int  // quarter turns clockwise
+	Mirror bool // flipped horizontally before rotating
+}
+
+// Design is a flattened layout.
+type Design struct {
+	Image     *image.RGBA
+	Manifest  *Manifest // parent pins plus module pins named instance.pin
+	Instances []PlacedInstance
+}
+
+// PlacedInstance is an instance of the flattened hierarchy.
+type PlacedInstance struct {
+	Path   string // instance names from the top, joined by dots
+	Module string
+	Bounds image.Rectangle
+	Rotate int
+	Mirror bool
+	Width  int // size of the module before the transformation
+	Height int
+}
+
+func LoadLayout(fileName string) (*Layout, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	layout := new(Layout)
+
+	err = json.NewDecoder(file).Decode(layout)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+
+	layout.fileName = fileName
+	layout.dir = filepath.Dir(fileName)
+
+	return layout, nil
+}
+
+// Path resolves a file name relative to the layout's directory.
+func (layout *Layout) Path(fileName string) string {
+	if fileName == "" || filepath.IsAbs(fileName) {
+		return fileName
+	}
+
+	return filepath.Join(layout.dir, fileName)
+}
+
+// Flatten draws every instance into one image and collects the pins and
+// the instance hierarchy.
+func (layout *Layout) Flatten() (*Design, error) {
+	return layout.flatten(nil)
+}
+
+func (layout *Layout) flatten(parents []string) (*Design, error) {
+	if layout.fileName != "" {
+		for _, parent := range parents {
+			if parent == filepath.Clean(layout.fileName) {
+				return nil, fmt.Errorf("%s includes itself", layout.fileName)
+			}
+		}
+		parents = append(parents, filepath.Clean(layout.fileName))
+	}
+
+	design := new(Design)
+	design.Manifest = NewManifest()
+	for name, bus := range layout.Pins {
+		design.Manifest.Pins[name] = bus
+	}
+
+	if layout.Image != "" {
+		img, err := decodeImageFile(layout.Path(layout.Image))
+		if err != nil {
+			return nil, err
+		}
+
+		bounds := img.Bounds()
+		design.Image = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(design.Image, design.Image.Rect, img, bounds.Min, draw.Src)
+	} else {
+		if layout.Width <= 0 || layout.Height <= 0 {
+			return nil, fmt.Errorf("layout without image needs a size")
+		}
+
+		design.Image = image.NewRGBA(image.Rect(0, 0, layout.Width, layout.Height))
+		draw.Draw(design.Image, design.Image.Rect, image.Black, image.Point{}, draw.Src)
+	}
+
+	for _, instance := range layout.Instances {
+		err := layout.place(design, instance, parents)
+		if err != nil {
+			return nil, fmt.Errorf("instance %s: %v", instance.Name, err)
+		}
+	}
+
+	return design, nil
+}
+
+func (layout *Layout) place(design *Design, instance Instance, parents []string) error {
+	if instance.Name == "" || strings.Contains(instance.Name, ".") {
+		return fmt.Errorf("instance names must be non-empty and without dots")
+	}
+
+	module, ok := layout.Modules[instance.Module]
+	if !ok {
+		return fmt.Errorf("unknown module %q", instance.Module)
+	}
+
+	// module image, pins and nested instances
+	var img image.Image
+	pins := make(map[string]Bus)
+	var nested []PlacedInstance
+
+	if module.Layout != "" {
+		sub, err := LoadLayout(layout.Path(module.Layout))
+		if err != nil {
+			return err
+		}
+		subDesign, err := sub.flatten(parents)
+		if err != nil {
+			return err
+		}
+
+		img = subDesign.Image
+		pins = subDesign.Manifest.Pins
+		nested = subDesign.Instances
+	} else {
+		var err error
+		img, err = decodeImageFile(layout.Path(module.Image))
+		if err != nil {
+			return err
+		}
+
+		if module.Manifest != "" {
+			manifest, err := LoadManifest(layout.Path(module.Manifest))
+			if err != nil {
+				return err
+			}
+			pins = manifest.Pins
+		}
+	}
+
+	bounds := img.Bounds()
+	placed := PlacedInstance{
+		Path:   instance.Name,
+		Module: instance.Module,
+		Rotate: ((instance.Rotate % 4) + 4) % 4,
+		Mirror: instance.Mirror,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}
+
+	size := placed.transform(Pin{bounds.Dx() - 1, bounds.Dy() - 1})
+	origin := placed.transform(Pin{0, 0})
+	placed.Bounds = image.Rect(
+		instance.X, instance.Y,
+		instance.X+abs(size.X-origin.X)+1, instance.Y+abs(size.Y-origin.Y)+1)
+
+	if !placed.Bounds.In(design.Image.Rect) {
+		return fmt.Errorf("module %dx%d at %d,%d does not fit the %dx%d image",
+			placed.Bounds.Dx(), placed.Bounds.Dy(), instance.X, instance.Y, design.Image.Rect.Dx(), design.Image.Rect.Dy())
+	}
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			p := placed.toParent(Pin{x, y})
+			design.Image.Set(p.X, p.Y, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	names := make([]string, 0, len(pins))
+	for name := range pins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		bus := make(Bus, len(pins[name]))
+		for i, pin := range pins[name] {
+			bus[i] = placed.toParent(pin)
+		}
+		design.Manifest.Pins[instance.Name+"."+name] = bus
+	}
+
+	design.Instances = append(design.Instances, placed)
+	for _, sub := range nested {
+		sub.Path = instance.Name + "." + sub.Path
+
+		// corners of the nested bounds in the module, mapped to the parent
+		a := placed.toParent(Pin{sub.Bounds.Min.X, sub.Bounds.Min.Y})
+		b := placed.toParent(Pin{sub.Bounds.Max.X - 1, sub.Bounds.Max.Y - 1})
+		sub.Bounds = image.Rect(a.X, a.Y, b.X, b.Y).Canon()
+		sub.Bounds.Max = sub.Bounds.Max.Add(image.Point{1, 1})
+
+		// compose the transformations
+		if placed.Mirror {
+			sub.Mirror = !sub.Mirror
+			sub.Rotate = (4 - sub.Rotate) % 4
+		}
+		sub.Rotate = (sub.Rotate + placed.Rotate) % 4
+
+		design.Instances = append(design.Instances, sub)
+	}
+
+	return nil
+}
+
+// transform mirrors and rotates a module pixel around the origin.
+func (instance PlacedInstance) transform(p Pin) Pin {
+	if instance.Mirror {
+		p.X = -p.X
+	}
+	for i := 0; i < instance.Rotate; i++ {
+		p = Pin{-p.Y, p.X}
+	}
+
+	return p
+}
+
+// offset returns the top left corner of the transformed module.
+func (instance PlacedInstance) offset() Pin {
+	min := Pin{0, 0}
+	for _, corner := range []Pin{{instance.Width - 1, 0}, {0, instance.Height - 1}, {instance.Width - 1, instance.Height - 1}} {
+		c := instance.transform(corner)
+		if c.X < min.X {
+			min.X = c.X
+		}
+		if c.Y < min.Y {
+			min.Y = c.Y
+		}
+	}
+
+	return min
+}
+
+// toParent maps a module pixel to the flattened image.
+func (instance PlacedInstance) toParent(p Pin) Pin {
+	q := instance.transform(p)
+	min := instance.offset()
+
+	return Pin{q.X - min.X + instance.Bounds.Min.X, q.Y - min.Y + instance.Bounds.Min.Y}
+}
+
+// toModule maps a pixel of the flattened image into the module.
+func (instance PlacedInstance) toModule(p Pin) Pin {
+	min := instance.offset()
+	q := Pin{p.X - instance.Bounds.Min.X + min.X, p.Y - instance.Bounds.Min.Y + min.Y}
+
+	for i := 0; i < instance.Rotate; i++ {
+		q = Pin{q.Y, -q.X}
+	}
+	if instance.Mirror {
+		q.X = -q.X
+	}
+
+	return q
+}
+
+// Locate returns the innermost instance containing a pixel of the design
+// and the pixel's position in that instance's module.
+func (design *Design) Locate(x, y int) (string, Pin, bool) {
+	for i := len(design.Instances) - 1; i >= 0; i-- {
+		instance := design.Instances[i]
+		if (image.Point{x, y}).In(instance.Bounds) {
+			return instance.Path, instance.toModule(Pin{x, y}), true
+		}
+	}
+
+	return "", Pin{}, false
+}
+
+func decodeImageFile(fileName string) (image.Image, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+
+	return img, nil
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
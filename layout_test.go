@@ -0,0 +1,126 @@
+package gobls_test
+
+import (
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// NOT gate module with pins on the left and right border
+	file, err := os.Create(filepath.Join(dir, "not.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(file, asciiImage(
+		".##..",
+		"##.##",
+		".##..",
+	))
+	file.Close()
+
+	write("not.json", `{"Pins": {"in": [{"X": 0, "Y": 1}], "out": [{"X": 4, "Y": 1}]}}`)
+	write("inner.json", `{
+		"Width": 20, "Height": 12,
+		"Modules": {"not": {"Image": "not.png", "Manifest": "not.json"}},
+		"Instances": [
+			{"Name": "a", "Module": "not", "X": 1, "Y": 1},
+			{"Name": "b", "Module": "not", "X": 6, "Y": 1},
+			{"Name": "c", "Module": "not", "X": 12, "Y": 1, "Rotate": 1},
+			{"Name": "d", "Module": "not", "X": 1, "Y": 6, "Mirror": true}
+		]
+	}`)
+	write("outer.json", `{
+		"Width": 30, "Height": 20,
+		"Modules": {"inner": {"Layout": "inner.json"}},
+		"Instances": [{"Name": "top", "Module": "inner", "X": 2, "Y": 2, "Mirror": true}]
+	}`)
+
+	layout, err := gobls.LoadLayout(filepath.Join(dir, "inner.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	design, err := layout.Flatten()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]gobls.Pin{
+		"a.in": {1, 2}, "b.out": {10, 2},
+		"c.in": {13, 1}, "c.out": {13, 5},
+		"d.in": {5, 7}, "d.out": {1, 7},
+	}
+	for name, pin := range want {
+		bus, err := design.Manifest.Bus(name)
+		if err != nil || len(bus) != 1 || bus[0] != pin {
+			t.Errorf("pin %s = %v, want %v", name, bus, pin)
+		}
+	}
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(design.Image)
+	for i := 0; i < 10; i++ {
+		simulator.Set(1, 2, true)
+		simulator.Simulate()
+	}
+	if !simulator.Get(10, 2) || simulator.Get(5, 2) {
+		t.Error("a and b are not chained")
+	}
+	if !simulator.Get(13, 5) || !simulator.Get(1, 7) {
+		t.Error("rotated or mirrored gate output low")
+	}
+
+	path, local, ok := design.Locate(13, 3)
+	if !ok || path != "c" || local != (gobls.Pin{2, 1}) {
+		t.Errorf("locate 13,3 = %s %v %v", path, local, ok)
+	}
+
+	// nested and mirrored
+	layout, err = gobls.LoadLayout(filepath.Join(dir, "outer.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	design, err = layout.Flatten()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, local, ok = design.Locate(8, 5)
+	if !ok || path != "top.c" || local != (gobls.Pin{2, 1}) {
+		t.Errorf("locate 8,5 = %s %v %v", path, local, ok)
+	}
+	if bus, _ := design.Manifest.Bus("top.c.out"); len(bus) != 1 || bus[0] != (gobls.Pin{8, 7}) {
+		t.Errorf("top.c.out = %v", bus)
+	}
+
+	// a layout including itself
+	write("loop.json", `{
+		"Width": 30, "Height": 20,
+		"Modules": {"loop": {"Layout": "loop.json"}},
+		"Instances": [{"Name": "loop", "Module": "loop"}]
+	}`)
+	layout, err = gobls.LoadLayout(filepath.Join(dir, "loop.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := layout.Flatten(); err == nil {
+		t.Error("no error for a recursive layout")
+	}
+}
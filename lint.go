@@ -0,0 +1,147 @@
+package gobls
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	LINT_PATTERN          = "pattern"          // NOT gate like 3x3 pattern no registered pattern matches
+	LINT_BORDER           = "border"           // gate or crossing cut by the image border
+	LINT_MULTIPLE_DRIVERS = "multiple-drivers" // net driven by more than one gate, implicit wired-OR
+	LINT_UNREAD_OUTPUT    = "unread-output"    // gate output net without readers
+	LINT_UNDRIVEN_INPUT   = "undriven-input"   // gate input net without driver or pin
+	LINT_ISOLATED_PIXEL   = "isolated-pixel"   // conductive pixel without neighbours
+)
+
+type LintIssue struct {
+	Kind    string
+	X, Y    int
+	Message string
+}
+
+func (issue LintIssue) String() string {
+	return fmt.Sprintf("%d,%d: %s: %s", issue.X, issue.Y, issue.Kind, issue.Message)
+}
+
+// Lint reports suspicious patterns of the loaded image. Nets under pins are
+// considered driven and read from outside.
+func (simulator *Simulator) Lint(pins Bus) []LintIssue {
+	issues := make([]LintIssue, 0)
+
+	pinNets := simulator.netSet(pins)
+
+	conductive := func(x, y int) bool {
+		return simulator.wireAt(x, y) >= 0
+	}
+
+	// patterns
+	patterns := currentPatterns()
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			if conductive(x, y) {
+				continue
+			}
+
+			up, right, down, left := conductive(x, y-1), conductive(x+1, y), conductive(x, y+1), conductive(x-1, y)
+			arms := boolCount(up, right, down, left)
+
+			border := x == 0 || y == 0 || x == simulator.width-1 || y == simulator.height-1
+			if border {
+				inside := boolCount(y > 0, x < simulator.width-1, y < simulator.height-1, x > 0)
+				if arms == inside && arms >= 3 {
+					issues = append(issues, LintIssue{LINT_BORDER, x, y, "gate or crossing touches the image border and is not recognized"})
+				}
+				continue
+			}
+
+			_, matched := patterns.match(simulator.netMap, simulator.width, x, y)
+			if matched {
+				continue
+			}
+
+			flag := 0
+			if conductive(x-1, y-1) {
+				flag |= 1 << 0
+			}
+			if conductive(x+1, y-1) {
+				flag |= 1 << 1
+			}
+			if conductive(x+1, y+1) {
+				flag |= 1 << 2
+			}
+			if conductive(x-1, y+1) {
+				flag |= 1 << 3
+			}
+
+			if arms == 4 {
+				issues = append(issues, LintIssue{LINT_PATTERN, x, y, fmt.Sprintf("unrecognized pattern, corners %04b", flag)})
+			} else if arms == 3 {
+				// a NOT gate with its input or output arm missing
+				var gateLike bool
+				switch flag {
+				case 1 + 2, 4 + 8: // vertical gate
+					gateLike = !up || !down
+				case 2 + 4, 8 + 1: // horizontal gate
+					gateLike = !left || !right
+				}
+				if gateLike {
+					issues = append(issues, LintIssue{LINT_PATTERN, x, y, "NOT gate with a missing input or output pixel"})
+				}
+			}
+		}
+	}
+
+	// nets
+	drivers := make(map[int][]*gate)
+	readers := make(map[int][]*gate)
+	for _, g := range simulator.gates {
+		drivers[g.outIdx] = append(drivers[g.outIdx], g)
+		readers[g.inIdx] = append(readers[g.inIdx], g)
+	}
+
+	for _, g := range simulator.gates {
+		if gates := drivers[g.outIdx]; len(gates) > 1 && gates[0] == g {
+			issues = append(issues, LintIssue{LINT_MULTIPLE_DRIVERS, g.out.x, g.out.y, fmt.Sprintf("net is driven by %d gates", len(gates))})
+		}
+		if len(readers[g.outIdx]) == 0 && !pinNets[g.outIdx] {
+			issues = append(issues, LintIssue{LINT_UNREAD_OUTPUT, g.out.x, g.out.y, "gate output is never read"})
+		}
+		if len(drivers[g.inIdx]) == 0 && !pinNets[g.inIdx] && readers[g.inIdx][0] == g {
+			issues = append(issues, LintIssue{LINT_UNDRIVEN_INPUT, g.in.x, g.in.y, "gate input has no driver or pin"})
+		}
+	}
+
+	// isolated pixels
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.wireAt(x, y)
+			if wire < 0 || pinNets[wire] {
+				continue
+			}
+
+			if !conductive(x, y-1) && !conductive(x+1, y) && !conductive(x, y+1) && !conductive(x-1, y) {
+				issues = append(issues, LintIssue{LINT_ISOLATED_PIXEL, x, y, "isolated pixel"})
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Y != issues[j].Y {
+			return issues[i].Y < issues[j].Y
+		}
+		return issues[i].X < issues[j].X
+	})
+
+	return issues
+}
+
+func boolCount(values ...bool) int {
+	count := 0
+	for _, value := range values {
+		if value {
+			count++
+		}
+	}
+	return count
+}
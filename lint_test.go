@@ -0,0 +1,94 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestLint(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(
+		"............",
+		"....##......",
+		".####.####..",
+		"....##......",
+		"..........#.",
+		"..###.......",
+		"..#.#.......",
+		"..###.......",
+	))
+
+	issues := simulator.Lint(gobls.Bus{{1, 2}})
+
+	want := map[string]gobls.Pin{
+		gobls.LINT_UNREAD_OUTPUT:  {6, 2},
+		gobls.LINT_PATTERN:        {3, 6},
+		gobls.LINT_ISOLATED_PIXEL: {10, 4},
+	}
+	for _, issue := range issues {
+		pin, ok := want[issue.Kind]
+		if !ok || pin.X != issue.X || pin.Y != issue.Y {
+			t.Errorf("unexpected issue %v", issue)
+		}
+		delete(want, issue.Kind)
+	}
+	for kind, pin := range want {
+		t.Errorf("missing %s issue at %d,%d", kind, pin.X, pin.Y)
+	}
+}
+
+func TestLintGates(t *testing.T) {
+	// two NOT gates whose outputs are wired together at x=5
+	wiredOr := []string{
+		"..##.....",
+		"###.##...",
+		"..##.#...",
+		".....#...",
+		"..##.#...",
+		"###.##...",
+		"..##.....",
+	}
+
+	cases := []struct {
+		name string
+		rows []string
+		pins gobls.Bus
+		want []gobls.LintIssue
+	}{
+		{"gate in column 0", []string{
+			"##..",
+			".###",
+			"##..",
+		}, nil, []gobls.LintIssue{{Kind: gobls.LINT_BORDER, X: 0, Y: 1}}},
+		{"gate in the last row", []string{
+			"....",
+			"###.",
+			"#.#.",
+		}, nil, []gobls.LintIssue{{Kind: gobls.LINT_BORDER, X: 1, Y: 2}}},
+		// the pins on the inputs keep them from being undriven
+		{"wired outputs", wiredOr, gobls.Bus{{0, 1}, {0, 5}, {5, 3}}, []gobls.LintIssue{
+			{Kind: gobls.LINT_MULTIPLE_DRIVERS, X: 4, Y: 1},
+		}},
+		{"input without pin", wiredOr, gobls.Bus{{0, 5}, {5, 3}}, []gobls.LintIssue{
+			{Kind: gobls.LINT_UNDRIVEN_INPUT, X: 2, Y: 1},
+			{Kind: gobls.LINT_MULTIPLE_DRIVERS, X: 4, Y: 1},
+		}},
+	}
+
+	for _, c := range cases {
+		simulator := gobls.NewSimulator()
+		simulator.LoadImage(asciiImage(c.rows...))
+
+		issues := simulator.Lint(c.pins)
+		if len(issues) != len(c.want) {
+			t.Errorf("%s: issues %v, want %v", c.name, issues, c.want)
+			continue
+		}
+		for i, issue := range issues {
+			if issue.Kind != c.want[i].Kind || issue.X != c.want[i].X || issue.Y != c.want[i].Y {
+				t.Errorf("%s: issue %v, want %s at %d,%d", c.name, issue, c.want[i].Kind, c.want[i].X, c.want[i].Y)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package gobls
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Manifest names pin groups of a bitmap and lists the devices attached to
+// them. It is stored as JSON next to the image:
+//
+//	{
+//		"Pins": {
+//			"addr": [{"X": 10, "Y": 4}, {"X": 10, "Y": 8}],
+//			"data": [{"X": 40, "Y": 4}]
+//		},
+//		"Devices": [
+//			{"Type": "rom", "Address": "addr", "Data": "data", "File": "prog.hex"}
+//		]
+//	}
+type Manifest struct {
+	Pins    map[string]Bus
+	Devices []json.RawMessage
+
+	dir string
+}
+
+// DeviceBuilder creates a device from its manifest entry.
+type DeviceBuilder func(manifest *Manifest, config json.RawMessage) (Device, error)
+
+var deviceBuilders = make(map[string]DeviceBuilder)
+
+// RegisterDevice makes a device type available to manifests.
+func RegisterDevice(typeName string, builder DeviceBuilder) {
+	deviceBuilders[typeName] = builder
+}
+
+func NewManifest() *Manifest {
+	manifest := new(Manifest)
+	manifest.Pins = make(map[string]Bus)
+
+	return manifest
+}
+
+func LoadManifest(fileName string) (*Manifest, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifest := NewManifest()
+
+	err = json.NewDecoder(file).Decode(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+
+	if manifest.Pins == nil {
+		manifest.Pins = make(map[string]Bus)
+	}
+	manifest.dir = filepath.Dir(fileName)
+
+	return manifest, nil
+}
+
+func (manifest *Manifest) Save(fileName string) error {
+	bytes, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(bytes)
+
+	return err
+}
+
+// Bus looks up a named pin group. An empty name gives an empty bus.
+func (manifest *Manifest) Bus(name string) (Bus, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	bus, ok := manifest.Pins[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown pin group %q", name)
+	}
+
+	return bus, nil
+}
+
+// Path resolves a file name relative to the manifest's directory.
+func (manifest *Manifest) Path(fileName string) string {
+	if fileName == "" || filepath.IsAbs(fileName) {
+		return fileName
+	}
+
+	return filepath.Join(manifest.dir, fileName)
+}
+
+// Attach builds every device of the manifest and attaches it to the simulator.
+func (manifest *Manifest) Attach(simulator *Simulator) error {
+	for i, config := range manifest.Devices {
+		header := struct {
+			Type string
+		}{}
+
+		err := json.Unmarshal(config, &header)
+		if err != nil {
+			return fmt.Errorf("device %d: %v", i, err)
+		}
+
+		builder, ok := deviceBuilders[header.Type]
+		if !ok {
+			return fmt.Errorf("device %d: unknown type %q", i, header.Type)
+		}
+
+		device, err := builder(manifest, config)
+		if err != nil {
+			return fmt.Errorf("device %d (%s): %v", i, header.Type, err)
+		}
+
+		simulator.Attach(device)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package gobls
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	MAX_ADDRESS_WIDTH = 24
+	MAX_DATA_WIDTH    = 64
+)
+
+// Memory is a ROM or RAM bound to address, data and enable pin groups.
+//
+// An access starts whenever the address or the enable pins change and
+// completes after Latency simulation steps. A read drives the data pins,
+// a write samples them. Enable groups are active when all their pins are
+// high. An empty read enable group is always active, an empty write enable
+// group never is.
+type Memory struct {
+	Address     Bus
+	Data        Bus
+	ReadEnable  Bus
+	WriteEnable Bus
+
+	AddressWidth int
+	DataWidth    int
+	Latency      int
+
+	Words []uint64
+
+	writable bool
+	loaded   []uint64 // words given to Load, restored by Reset
+
+	address uint64
+	read    bool
+	write   bool
+	wait    int
+}
+
+type memoryConfig struct {
+	Address     string
+	Data        string
+	ReadEnable  string
+	WriteEnable string
+
+	AddressWidth int
+	DataWidth    int
+	Latency      int
+
+	File string // Intel HEX or raw binary contents
+}
+
+func init() {
+	RegisterDevice("rom", buildMemory(false))
+	RegisterDevice("ram", buildMemory(true))
+}
+
+func buildMemory(writable bool) DeviceBuilder {
+	return func(manifest *Manifest, raw json.RawMessage) (Device, error) {
+		config := memoryConfig{}
+		err := json.Unmarshal(raw, &config)
+		if err != nil {
+			return nil, err
+		}
+
+		address, err := manifest.Bus(config.Address)
+		if err != nil {
+			return nil, err
+		}
+		data, err := manifest.Bus(config.Data)
+		if err != nil {
+			return nil, err
+		}
+		readEnable, err := manifest.Bus(config.ReadEnable)
+		if err != nil {
+			return nil, err
+		}
+		writeEnable, err := manifest.Bus(config.WriteEnable)
+		if err != nil {
+			return nil, err
+		}
+
+		var memory *Memory
+		if writable {
+			memory, err = NewRAM(address, data, config.AddressWidth, config.DataWidth)
+		} else {
+			memory, err = NewROM(address, data, config.AddressWidth, config.DataWidth, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the file is read no further than the memory reaches
+		if config.File != "" {
+			words, err := ReadMemoryFile(manifest.Path(config.File), memory.DataWidth, len(memory.Words))
+			if err != nil {
+				return nil, err
+			}
+			err = memory.Load(words)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		memory.ReadEnable = readEnable
+		memory.WriteEnable = writeEnable
+		memory.Latency = config.Latency
+
+		return memory, nil
+	}
+}
+
+// NewROM creates a read only memory. A width of 0 takes the size of the bus.
+func NewROM(address, data Bus, addressWidth, dataWidth int, words []uint64) (*Memory, error) {
+	memory, err := newMemory(address, data, addressWidth, dataWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	err = memory.Load(words)
+	if err != nil {
+		return nil, err
+	}
+
+	return memory, nil
+}
+
+// NewRAM creates a zero filled read write memory.
+func NewRAM(address, data Bus, addressWidth, dataWidth int) (*Memory, error) {
+	memory, err := newMemory(address, data, addressWidth, dataWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	memory.writable = true
+
+	return memory, nil
+}
+
+func newMemory(address, data Bus, addressWidth, dataWidth int) (*Memory, error) {
+	if addressWidth == 0 {
+		addressWidth = len(address)
+	}
+	if dataWidth == 0 {
+		dataWidth = len(data)
+	}
+
+	if addressWidth > len(address) {
+		return nil, fmt.Errorf("address width %d is wider than the address bus (%d pins)", addressWidth, len(address))
+	}
+	if addressWidth > MAX_ADDRESS_WIDTH {
+		return nil, fmt.Errorf("address width %d is over the limit of %d", addressWidth, MAX_ADDRESS_WIDTH)
+	}
+	if dataWidth > len(data) || dataWidth > MAX_DATA_WIDTH {
+		return nil, fmt.Errorf("invalid data width %d for a data bus of %d pins", dataWidth, len(data))
+	}
+	if dataWidth == 0 {
+		return nil, errors.New("memory has no data pins")
+	}
+
+	memory := new(Memory)
+	memory.Address = address
+	memory.Data = data
+	memory.AddressWidth = addressWidth
+	memory.DataWidth = dataWidth
+	memory.Words = make([]uint64, 1<<uint(addressWidth))
+
+	return memory, nil
+}
+
+// Load copies words to the start of the memory.
+func (memory *Memory) Load(words []uint64) error {
+	if len(words) > len(memory.Words) {
+		return fmt.Errorf("image has %d words, address space has %d", len(words), len(memory.Words))
+	}
+
+	for i, word := range words {
+		memory.Words[i] = word & memory.dataMask()
+	}
+	if len(words) > len(memory.loaded) {
+		memory.loaded = append(memory.loaded, make([]uint64, len(words)-len(memory.loaded))...)
+	}
+	copy(memory.loaded, memory.Words[:len(words)])
+
+	return nil
+}
+
+// Reset restores the loaded contents, zero elsewhere, and cancels the
+// access in progress.
+func (memory *Memory) Reset() {
+	copy(memory.Words, memory.loaded)
+	for i := len(memory.loaded); i < len(memory.Words); i++ {
+		memory.Words[i] = 0
+	}
+
+	memory.address = 0
+	memory.read = false
+	memory.write = false
+	memory.wait = 0
+}
+
+func (memory *Memory) Writable() bool {
+	return memory.writable
+}
+
+func (memory *Memory) dataMask() uint64 {
+	if memory.DataWidth >= 64 {
+		return ^uint64(0)
+	}
+
+	return 1<<uint(memory.DataWidth) - 1
+}
+
+func (memory *Memory) Step(simulator *Simulator) {
+	address := simulator.ReadBus(memory.Address[:memory.AddressWidth])
+	read := simulator.busEnabled(memory.ReadEnable, true)
+	write := memory.writable && simulator.busEnabled(memory.WriteEnable, false)
+
+	if address != memory.address || read != memory.read || write != memory.write {
+		memory.address = address
+		memory.read = read
+		memory.write = write
+		memory.wait = memory.Latency
+	}
+
+	if memory.wait > 0 {
+		memory.wait--
+		return
+	}
+
+	data := memory.Data[:memory.DataWidth]
+
+	if write {
+		memory.Words[address] = simulator.ReadBus(data) & memory.dataMask()
+	} else if read {
+		simulator.WriteBus(data, memory.Words[address])
+	}
+}
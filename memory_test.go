@@ -0,0 +1,102 @@
+package gobls_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestReadIntelHex(t *testing.T) {
+	data, err := gobls.ReadIntelHex(strings.NewReader(`
+:03000200010203F5
+:020000040001F9
+:0100000055AA
+:00000001FF
+`), 0x20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) != 0x10001 {
+		t.Fatalf("len = %d, want %d", len(data), 0x10001)
+	}
+	if data[2] != 1 || data[3] != 2 || data[4] != 3 || data[0x10000] != 0x55 {
+		t.Errorf("unexpected contents % x ... %x", data[:5], data[0x10000])
+	}
+
+	_, err = gobls.ReadIntelHex(strings.NewReader(":03000200010203F6\n"), 0x20000)
+	if err == nil {
+		t.Error("checksum error not detected")
+	}
+
+	// a record near 4 GiB must not grow the image
+	_, err = gobls.ReadIntelHex(strings.NewReader(":02000004FFFFFC\n:0100000055AA\n"), 0x20000)
+	if err == nil {
+		t.Error("record beyond the memory size not detected")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(
+		"#.#.#.#",
+		".......",
+		"#.#.#.#",
+	))
+
+	address := gobls.Bus{{0, 0}, {2, 0}}
+	data := gobls.Bus{{0, 2}, {2, 2}, {4, 2}}
+	we := gobls.Bus{{6, 0}}
+
+	rom, err := gobls.NewROM(address, data, 0, 0, []uint64{1, 2, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rom.Latency = 2
+	simulator.Attach(rom)
+
+	simulator.WriteBus(address, 2)
+	for i := 0; i < 2; i++ {
+		simulator.Simulate()
+		if got := simulator.ReadBus(data); got != 0 {
+			t.Fatalf("step %d: data = %d before latency elapsed", i, got)
+		}
+	}
+	simulator.Simulate()
+	if got := simulator.ReadBus(data); got != 7 {
+		t.Fatalf("rom data = %d, want 7", got)
+	}
+
+	simulator.DetachAll()
+
+	ram, err := gobls.NewRAM(address, data, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ram.WriteEnable = we
+	simulator.Attach(ram)
+
+	simulator.WriteBus(address, 1)
+	simulator.WriteBus(data, 5)
+	simulator.WriteBus(we, 1)
+	simulator.Simulate()
+	simulator.WriteBus(we, 0)
+	simulator.WriteBus(data, 0)
+	simulator.Simulate()
+
+	if got := simulator.ReadBus(data); got != 5 {
+		t.Errorf("ram data = %d, want 5", got)
+	}
+
+	// reset returns to the loaded contents
+	err = ram.Load([]uint64{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ram.Words[0] = 6
+	ram.Reset()
+	if ram.Words[0] != 3 || ram.Words[1] != 0 {
+		t.Errorf("ram words %v after reset, want [3 0 ...]", ram.Words[:2])
+	}
+}
@@ -0,0 +1,334 @@
+package gobls
+
+import (
+	"image"
+	"image/color"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// EXTRACT_BAND_HEIGHT is the number of rows a worker labels at once.
+const EXTRACT_BAND_HEIGHT = 256
+
+// extraction is the circuit found in an image. The net map holds the net
+// index of every pixel row by row, -1 for insulation.
+type extraction struct {
+	width, height int
+	netMap        []int32
+	nets          int
+
+	gates     []*gate
+	crossings []point
+
+	patterns *patternSet // taken at the start, one set for the whole image
+}
+
+type extractionBand struct {
+	y0, y1 int
+	runs   int32 // run count, then the band's first run index
+
+	gates     []*gate
+	crossings []point
+}
+
+// extractNets labels the image in horizontal bands in parallel. The runs of
+// conductive pixels are counted first so every band numbers its horizontal
+// runs in its own part of one union-find array and joins the runs touching
+// vertically; then the runs are joined across the bands and through the
+// crossings and the nets are numbered in the order of their first pixel.
+// Besides the net map of 4 bytes per pixel only 4 bytes per run are used.
+func extractNets(img image.Image) *extraction {
+	e := new(extraction)
+	e.width = img.Bounds().Max.X
+	e.height = img.Bounds().Max.Y
+	if e.width < 0 {
+		e.width = 0
+	}
+	if e.height < 0 {
+		e.height = 0
+	}
+	e.netMap = make([]int32, e.width*e.height)
+	e.patterns = currentPatterns()
+
+	bands := make([]*extractionBand, 0, e.height/EXTRACT_BAND_HEIGHT+1)
+	for y := 0; y < e.height; y += EXTRACT_BAND_HEIGHT {
+		band := &extractionBand{y0: y, y1: y + EXTRACT_BAND_HEIGHT}
+		if band.y1 > e.height {
+			band.y1 = e.height
+		}
+		bands = append(bands, band)
+	}
+
+	// first run index of every band
+	read := conductiveRows(img)
+	parallel(len(bands), func(i int) {
+		bands[i].runs = e.countRuns(bands[i], read)
+	})
+	var runs int32
+	for _, band := range bands {
+		count := band.runs
+		band.runs = runs
+		runs += count
+	}
+
+	// label the runs of every band, the bands own disjoint parts of parent
+	parent := make([]int32, runs)
+	parallel(len(bands), func(i int) {
+		e.labelBand(bands[i], read, parent)
+	})
+
+	// gates and crossings, the neighbours of a band's pixels are read only
+	parallel(len(bands), func(i int) {
+		e.matchBand(bands[i])
+	})
+
+	// join runs across the band borders and through the crossings
+	for i := 1; i < len(bands); i++ {
+		band := bands[i]
+		above := e.netMap[(band.y0-1)*e.width : band.y0*e.width]
+		below := e.netMap[band.y0*e.width : (band.y0+1)*e.width]
+		for x := range above {
+			if above[x] >= 0 && below[x] >= 0 {
+				union(parent, above[x], below[x])
+			}
+		}
+	}
+	for _, band := range bands {
+		for _, p := range band.crossings {
+			union(parent, e.at(p.x, p.y-1), e.at(p.x, p.y+1))
+			union(parent, e.at(p.x-1, p.y), e.at(p.x+1, p.y))
+		}
+		e.crossings = append(e.crossings, band.crossings...)
+		e.gates = append(e.gates, band.gates...)
+	}
+
+	// number the nets in the order of their first run, in place: a run's
+	// parent is never above it, so it already holds the net of their root
+	for i, p := range parent {
+		if p == int32(i) {
+			parent[i] = int32(e.nets)
+			e.nets++
+		} else {
+			parent[i] = parent[p]
+		}
+	}
+
+	parallel(len(bands), func(i int) {
+		row := e.netMap[bands[i].y0*e.width : bands[i].y1*e.width]
+		for j, label := range row {
+			if label >= 0 {
+				row[j] = parent[label]
+			}
+		}
+	})
+
+	for _, g := range e.gates {
+		g.inIdx = int(e.at(g.in.x, g.in.y))
+		g.outIdx = int(e.at(g.out.x, g.out.y))
+	}
+
+	return e
+}
+
+func (e *extraction) at(x, y int) int32 {
+	return e.netMap[y*e.width+x]
+}
+
+// countRuns counts the horizontal runs of conductive pixels of a band.
+func (e *extraction) countRuns(band *extractionBand, read func(y int, row []bool)) int32 {
+	conductive := make([]bool, e.width)
+
+	var runs int32
+	for y := band.y0; y < band.y1; y++ {
+		read(y, conductive)
+		for x, c := range conductive {
+			if c && (x == 0 || !conductive[x-1]) {
+				runs++
+			}
+		}
+	}
+
+	return runs
+}
+
+// labelBand numbers the horizontal runs of a band from its first run index
+// on and joins the runs touching vertically.
+func (e *extraction) labelBand(band *extractionBand, read func(y int, row []bool), parent []int32) {
+	conductive := make([]bool, e.width)
+	next := band.runs
+
+	for y := band.y0; y < band.y1; y++ {
+		read(y, conductive)
+
+		row := e.netMap[y*e.width : (y+1)*e.width]
+		for x, c := range conductive {
+			if !c {
+				row[x] = -1
+				continue
+			}
+
+			if x > 0 && conductive[x-1] {
+				row[x] = row[x-1]
+			} else {
+				row[x] = next
+				parent[next] = next
+				next++
+			}
+
+			if y > band.y0 {
+				if above := e.netMap[(y-1)*e.width+x]; above >= 0 {
+					union(parent, above, row[x])
+				}
+			}
+		}
+	}
+}
+
+// matchBand collects the gates and crossings centered in a band.
+func (e *extraction) matchBand(band *extractionBand) {
+	y0, y1 := band.y0, band.y1
+	if y0 < 1 {
+		y0 = 1
+	}
+	if y1 > e.height-1 {
+		y1 = e.height - 1
+	}
+
+	for y := y0; y < y1; y++ {
+		for x := 1; x < e.width-1; x++ {
+			if e.at(x, y) >= 0 {
+				continue
+			}
+
+			pattern, ok := e.patterns.match(e.netMap, e.width, x, y)
+			if !ok {
+				continue
+			}
+
+			switch pattern.Kind {
+			case PATTERN_CROSSING:
+				band.crossings = append(band.crossings, point{x, y})
+			case PATTERN_NOT, PATTERN_BUFFER:
+				band.gates = append(band.gates, &gate{
+					at:     point{x, y},
+					in:     point{x + pattern.In.X, y + pattern.In.Y},
+					out:    point{x + pattern.Out.X, y + pattern.Out.Y},
+					dir:    pattern.Dir,
+					buffer: pattern.Kind == PATTERN_BUFFER,
+				})
+			}
+		}
+	}
+}
+
+func find(parent []int32, i int32) int32 {
+	for parent[i] != i {
+		parent[i] = parent[parent[i]]
+		i = parent[i]
+	}
+
+	return i
+}
+
+// union joins two sets under the lower root.
+func union(parent []int32, a, b int32) {
+	a, b = find(parent, a), find(parent, b)
+	if a < b {
+		parent[b] = a
+	} else if b < a {
+		parent[a] = b
+	}
+}
+
+// parallel calls f for 0 to n-1 on every processor.
+func parallel(n int, f func(i int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// conductiveRows returns a function filling a row with the conductivity of
+// the image's pixels, reading the pixel buffers of the common image types
+// directly.
+func conductiveRows(img image.Image) func(y int, row []bool) {
+	bounds := img.Bounds()
+
+	// pixels left of the bounds are insulating
+	clip := func(y int, row []bool) (int, int) {
+		for x := range row {
+			row[x] = false
+		}
+		if y < bounds.Min.Y || y >= bounds.Max.Y {
+			return 0, 0
+		}
+
+		if bounds.Min.X < 0 {
+			return 0, bounds.Max.X
+		}
+		return bounds.Min.X, bounds.Max.X
+	}
+
+	switch img := img.(type) {
+	case *image.RGBA:
+		return func(y int, row []bool) {
+			x0, x1 := clip(y, row)
+			for x := x0; x < x1; x++ {
+				i := img.PixOffset(x, y)
+				row[x] = bright(color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}.RGBA())
+			}
+		}
+	case *image.NRGBA:
+		return func(y int, row []bool) {
+			x0, x1 := clip(y, row)
+			for x := x0; x < x1; x++ {
+				i := img.PixOffset(x, y)
+				row[x] = bright(color.NRGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}.RGBA())
+			}
+		}
+	case *image.Gray:
+		return func(y int, row []bool) {
+			x0, x1 := clip(y, row)
+			for x := x0; x < x1; x++ {
+				row[x] = bright(color.Gray{img.Pix[img.PixOffset(x, y)]}.RGBA())
+			}
+		}
+	case *image.Paletted:
+		palette := make([]bool, 256)
+		for i, c := range img.Palette {
+			palette[i] = isConductive(c)
+		}
+
+		return func(y int, row []bool) {
+			x0, x1 := clip(y, row)
+			for x := x0; x < x1; x++ {
+				row[x] = palette[img.Pix[img.PixOffset(x, y)]]
+			}
+		}
+	}
+
+	return func(y int, row []bool) {
+		x0, x1 := clip(y, row)
+		for x := x0; x < x1; x++ {
+			row[x] = isConductive(img.At(x, y))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package gobls_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"runtime"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestBandBorders(t *testing.T) {
+	// a wire down the image with a crossing on the first band border and a
+	// NOT gate at the bottom
+	height := 3*gobls.EXTRACT_BAND_HEIGHT + 10
+	img := image.NewRGBA(image.Rect(0, 0, 5, height))
+	draw.Draw(img, img.Rect, image.Black, image.Point{}, draw.Src)
+	for y := 0; y < height-4; y++ {
+		img.Set(2, y, color.White)
+	}
+
+	crossing := gobls.EXTRACT_BAND_HEIGHT
+	img.Set(2, crossing, color.Black)
+	img.Set(1, crossing, color.White)
+	img.Set(3, crossing, color.White)
+
+	gate := height - 4
+	img.Set(2, gate, color.Black)
+	for _, p := range []image.Point{{1, gate - 1}, {3, gate - 1}, {1, gate}, {3, gate}, {2, gate + 1}, {2, gate + 2}, {2, gate + 3}} {
+		img.Set(p.X, p.Y, color.White)
+	}
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(img)
+
+	stats := simulator.Stats()
+	if stats.Nets != 3 || stats.Crossings != 1 || stats.GatesDown != 1 {
+		t.Fatalf("nets %d, crossings %d, gates down %d", stats.Nets, stats.Crossings, stats.GatesDown)
+	}
+
+	for i := 0; i < 10; i++ {
+		simulator.Set(2, 0, true)
+		simulator.Simulate()
+	}
+	if !simulator.Get(2, gate-2) || simulator.Get(2, gate+3) || simulator.Get(1, crossing) {
+		t.Error("wire not connected across the band borders")
+	}
+}
+
+func TestImageFormats(t *testing.T) {
+	rgba := asciiImage(
+		".......",
+		"..##...",
+		"###.###",
+		"..##...",
+		".......",
+	)
+	bounds := rgba.Bounds()
+
+	nrgba := image.NewNRGBA(bounds)
+	draw.Draw(nrgba, bounds, rgba, image.Point{}, draw.Src)
+	nrgba.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 100}) // too transparent to conduct
+
+	gray := image.NewGray(bounds)
+	draw.Draw(gray, bounds, rgba, image.Point{}, draw.Src)
+
+	paletted := image.NewPaletted(bounds, color.Palette{color.Black, color.White})
+	draw.Draw(paletted, bounds, rgba, image.Point{}, draw.Src)
+
+	// offset bounds, pixels left and above are insulating
+	sub := rgba.SubImage(image.Rect(1, 0, 7, 5))
+
+	for _, img := range []image.Image{rgba, nrgba, gray, paletted, sub} {
+		simulator := gobls.NewSimulator()
+		simulator.LoadImage(img)
+		simulator.Simulate()
+
+		stats := simulator.Stats()
+		if stats.Nets != 2 || stats.GatesRight != 1 || !simulator.Get(5, 2) {
+			t.Errorf("%T: nets %d, gates right %d", img, stats.Nets, stats.GatesRight)
+		}
+	}
+}
+
+// wireImage has horizontal wires on every other row, cut every 8 pixels, and
+// vertical wires joining them every 64 pixels.
+func wireImage(width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y += 2 {
+		for x := 0; x < width; x++ {
+			if x%8 != 7 {
+				img.Pix[y*img.Stride+x] = 255
+			}
+		}
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x += 64 {
+			img.Pix[y*img.Stride+x] = 255
+		}
+	}
+
+	return img
+}
+
+// loadAllocated returns the bytes allocated by loading an image, an upper
+// bound of the memory extraction needs at its peak.
+func loadAllocated(simulator *gobls.Simulator, img image.Image) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	simulator.LoadImage(img)
+	runtime.ReadMemStats(&after)
+
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestExtractionMemory(t *testing.T) {
+	width, height := 2048, 2048
+	img := wireImage(width, height)
+
+	// the net map takes 4 bytes per pixel, the runs a quarter byte here
+	allocated := loadAllocated(gobls.NewSimulator(), img)
+	perPixel := float64(allocated) / float64(width*height)
+	t.Logf("%.2f bytes per pixel", perPixel)
+	if perPixel > 5 {
+		t.Errorf("loading allocated %.2f bytes per pixel", perPixel)
+	}
+}
+
+// BenchmarkLoadImage reports the memory loading takes, e.g. 32k x 32k with
+// -benchtime 1x and a large enough -timeout:
+//
+//	go test -run none -bench LoadImage -benchtime 1x
+func BenchmarkLoadImage(b *testing.B) {
+	width, height := 8192, 8192
+	img := wireImage(width, height)
+
+	var allocated uint64
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		allocated = loadAllocated(gobls.NewSimulator(), img)
+	}
+
+	b.ReportMetric(float64(allocated)/float64(width*height), "B/pixel")
+	b.ReportMetric(float64(allocated)/(1<<20), "MB/load")
+}
@@ -0,0 +1,137 @@
+package gobls
+
+import (
+	"errors"
+	"math"
+)
+
+// Path is a chain of NOT gates between input and output nets.
+type Path struct {
+	Gates    []Pin // gate centers, from input to output
+	MaxSteps int   // worst case number of steps for a change to pass the chain
+}
+
+// GateDelay returns the worst case number of Simulate steps a gate needs to
+// follow a change of its input.
+func GateDelay() int {
+	return int(math.Ceil(1 / math.Min(TIME_RAISE, TIME_FALL)))
+}
+
+// CriticalPath finds the longest chain of gates from a gate reading one of
+// the input nets to a gate driving one of the output nets. Feedback loops
+// are cut where the search closes them, so a sequential circuit gives the
+// longest chain through its combinational part.
+func (simulator *Simulator) CriticalPath(inputs, outputs Bus) (*Path, error) {
+	inputNets := simulator.netSet(inputs)
+	outputNets := simulator.netSet(outputs)
+
+	readers := simulator.netReaders()
+
+	// topological order of the gates reachable from the inputs, skipping
+	// edges back into the current search path
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make([]int, len(simulator.gates))
+	order := make([]int, 0)
+
+	type frame struct {
+		gate int
+		next int
+	}
+	for i, g := range simulator.gates {
+		if !inputNets[g.inIdx] || marks[i] != unvisited {
+			continue
+		}
+
+		stack := []frame{{i, 0}}
+		marks[i] = visiting
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			fanout := readers[simulator.gates[top.gate].outIdx]
+
+			if top.next < len(fanout) {
+				next := fanout[top.next]
+				top.next++
+
+				if marks[next] == unvisited {
+					marks[next] = visiting
+					stack = append(stack, frame{next, 0})
+				}
+				continue
+			}
+
+			marks[top.gate] = visited
+			order = append(order, top.gate)
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	// longest chain ending at each gate, in reverse post order
+	position := make([]int, len(simulator.gates))
+	for i, gate := range order {
+		position[gate] = i
+	}
+
+	length := make([]int, len(simulator.gates))
+	prev := make([]int, len(simulator.gates))
+	for i := range prev {
+		prev[i] = -1
+	}
+
+	best := -1
+	for i := len(order) - 1; i >= 0; i-- {
+		gate := order[i]
+		g := simulator.gates[gate]
+
+		if inputNets[g.inIdx] && length[gate] == 0 {
+			length[gate] = 1
+		}
+		if length[gate] == 0 {
+			continue
+		}
+
+		for _, next := range readers[g.outIdx] {
+			// only forward edges of the order
+			if marks[next] != visited || position[next] >= position[gate] {
+				continue
+			}
+			if length[gate]+1 > length[next] {
+				length[next] = length[gate] + 1
+				prev[next] = gate
+			}
+		}
+
+		if outputNets[g.outIdx] && (best < 0 || length[gate] > length[best]) {
+			best = gate
+		}
+	}
+
+	if best < 0 {
+		return nil, errors.New("no gate path from the inputs to the outputs")
+	}
+
+	path := new(Path)
+	for gate := best; gate >= 0; gate = prev[gate] {
+		center := simulator.gates[gate].center()
+		path.Gates = append([]Pin{{center.x, center.y}}, path.Gates...)
+	}
+	path.MaxSteps = len(path.Gates) * GateDelay()
+
+	return path, nil
+}
+
+// netSet returns the nets under the pins.
+func (simulator *Simulator) netSet(pins Bus) map[int]bool {
+	nets := make(map[int]bool)
+
+	for _, pin := range pins {
+		if wire := simulator.wireAt(pin.X, pin.Y); wire >= 0 {
+			nets[wire] = true
+		}
+	}
+
+	return nets
+}
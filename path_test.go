@@ -0,0 +1,42 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func TestCriticalPath(t *testing.T) {
+	b := builder.New(16, 8)
+	first := b.Not(3, 2, gobls.DIR_RIGHT)
+	b.Not(6, 2, gobls.DIR_RIGHT)
+	last := b.Not(9, 2, gobls.DIR_RIGHT)
+
+	// a shorter branch from the first gate to the same output, its input
+	// touches the second gate's input
+	b.Not(6, 5, gobls.DIR_RIGHT)
+	b.Wire(7, 5, 11, 5)
+	b.Wire(last.Out.X, 2, 11, 5)
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(b.Image())
+
+	path, err := simulator.CriticalPath(gobls.Bus{{first.In[0].X, first.In[0].Y}}, gobls.Bus{{last.Out.X, last.Out.Y}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []gobls.Pin{{3, 2}, {6, 2}, {9, 2}}
+	if len(path.Gates) != len(want) {
+		t.Fatalf("path = %v, want %v", path.Gates, want)
+	}
+	for i := range want {
+		if path.Gates[i] != want[i] {
+			t.Fatalf("path = %v, want %v", path.Gates, want)
+		}
+	}
+	if path.MaxSteps != 3*gobls.GateDelay() {
+		t.Errorf("max steps = %d", path.MaxSteps)
+	}
+}
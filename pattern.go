@@ -0,0 +1,229 @@
+package gobls
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// components a pattern creates
+const (
+	PATTERN_CROSSING = iota // connects the up arm to the down arm and the left arm to the right arm
+	PATTERN_NOT             // NOT gate from In to Out
+	PATTERN_BUFFER          // one way buffer from In to Out
+)
+
+// Pattern is a 3x3 rule matched around every insulating pixel off the image
+// border. Kernel rows use '#' for conductive, '.' for insulating and '?' for
+// either; the center is always insulating.
+type Pattern struct {
+	Name    string
+	Kernel  [3]string
+	Kind    int
+	In, Out Pin // offsets from the center, for gates and buffers
+	Dir     int // direction of the output
+}
+
+// patternSet is a set of registered patterns. A set is never changed once
+// published, changes publish a copy, so loads running in parallel with
+// RegisterPattern or ResetPatterns see one consistent set.
+type patternSet struct {
+	patterns []Pattern
+
+	// table maps the 9 bit neighbourhood, row by row from the top left
+	// pixel, to an index of patterns or -1.
+	table [512]int
+}
+
+var (
+	patternLock    sync.Mutex   // serializes changes
+	activePatterns atomic.Value // *patternSet
+)
+
+func currentPatterns() *patternSet {
+	return activePatterns.Load().(*patternSet)
+}
+
+func init() {
+	ResetPatterns()
+}
+
+// DefaultPatterns returns the crossing and the four NOT gate orientations.
+func DefaultPatterns() []Pattern {
+	return []Pattern{
+		{Name: "crossing", Kind: PATTERN_CROSSING, Kernel: [3]string{
+			".#.",
+			"#.#",
+			".#.",
+		}},
+		{Name: "not up", Kind: PATTERN_NOT, In: Pin{0, 1}, Out: Pin{0, -1}, Dir: DIR_UP, Kernel: [3]string{
+			".#.",
+			"#.#",
+			"###",
+		}},
+		{Name: "not right", Kind: PATTERN_NOT, In: Pin{-1, 0}, Out: Pin{1, 0}, Dir: DIR_RIGHT, Kernel: [3]string{
+			"##.",
+			"#.#",
+			"##.",
+		}},
+		{Name: "not down", Kind: PATTERN_NOT, In: Pin{0, -1}, Out: Pin{0, 1}, Dir: DIR_DOWN, Kernel: [3]string{
+			"###",
+			"#.#",
+			".#.",
+		}},
+		{Name: "not left", Kind: PATTERN_NOT, In: Pin{1, 0}, Out: Pin{-1, 0}, Dir: DIR_LEFT, Kernel: [3]string{
+			".##",
+			"#.#",
+			".##",
+		}},
+	}
+}
+
+// ResetPatterns replaces the registered patterns with the default ones.
+func ResetPatterns() {
+	patternLock.Lock()
+	defer patternLock.Unlock()
+
+	set := new(patternSet)
+	for i := range set.table {
+		set.table[i] = -1
+	}
+
+	for _, pattern := range DefaultPatterns() {
+		err := set.add(pattern)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	activePatterns.Store(set)
+}
+
+// Patterns returns the registered patterns.
+func Patterns() []Pattern {
+	return append([]Pattern(nil), currentPatterns().patterns...)
+}
+
+// RegisterPattern adds a rule used by LoadImage from the next load on. A
+// pattern matching a neighbourhood another pattern already matches is
+// rejected.
+func RegisterPattern(pattern Pattern) error {
+	patternLock.Lock()
+	defer patternLock.Unlock()
+
+	old := currentPatterns()
+	set := &patternSet{
+		patterns: append([]Pattern(nil), old.patterns...),
+		table:    old.table,
+	}
+
+	err := set.add(pattern)
+	if err != nil {
+		return err
+	}
+
+	activePatterns.Store(set)
+
+	return nil
+}
+
+func (set *patternSet) add(pattern Pattern) error {
+	codes, err := pattern.codes()
+	if err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		if index := set.table[code]; index >= 0 {
+			return fmt.Errorf("pattern %q overlaps pattern %q", pattern.Name, set.patterns[index].Name)
+		}
+	}
+
+	for _, code := range codes {
+		set.table[code] = len(set.patterns)
+	}
+	set.patterns = append(set.patterns, pattern)
+
+	return nil
+}
+
+// codes validates the kernel and returns the neighbourhoods it matches.
+func (pattern Pattern) codes() ([]int, error) {
+	var must, mustNot int
+	for y, row := range pattern.Kernel {
+		if len(row) != 3 {
+			return nil, fmt.Errorf("pattern %q: kernel rows must have 3 pixels", pattern.Name)
+		}
+
+		for x, c := range row {
+			bit := 1 << uint(y*3+x)
+			switch c {
+			case '#':
+				must |= bit
+			case '.':
+				mustNot |= bit
+			case '?':
+			default:
+				return nil, fmt.Errorf("pattern %q: unknown kernel pixel %q", pattern.Name, c)
+			}
+		}
+	}
+
+	if mustNot&(1<<4) == 0 {
+		return nil, fmt.Errorf("pattern %q: center must be insulating", pattern.Name)
+	}
+
+	conductive := func(p Pin) bool {
+		return -1 <= p.X && p.X <= 1 && -1 <= p.Y && p.Y <= 1 && must&(1<<uint((p.Y+1)*3+p.X+1)) != 0
+	}
+
+	switch pattern.Kind {
+	case PATTERN_CROSSING:
+		if !conductive(Pin{0, -1}) || !conductive(Pin{1, 0}) || !conductive(Pin{0, 1}) || !conductive(Pin{-1, 0}) {
+			return nil, fmt.Errorf("pattern %q: crossing arms must be conductive", pattern.Name)
+		}
+	case PATTERN_NOT, PATTERN_BUFFER:
+		if !conductive(pattern.In) || !conductive(pattern.Out) {
+			return nil, fmt.Errorf("pattern %q: in and out must be conductive kernel pixels", pattern.Name)
+		}
+		if pattern.Dir < DIR_UP || pattern.Dir > DIR_LEFT {
+			return nil, fmt.Errorf("pattern %q: unknown direction %d", pattern.Name, pattern.Dir)
+		}
+	default:
+		return nil, errors.New("unknown pattern kind")
+	}
+
+	codes := make([]int, 0)
+	for code := 0; code < 512; code++ {
+		if code&must == must && code&mustNot == 0 {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes, nil
+}
+
+// patternCode returns the 9 bit neighbourhood of a pixel off the border.
+func patternCode(netMap []int32, width, x, y int) int {
+	code := 0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if netMap[(y+dy)*width+x+dx] >= 0 {
+				code |= 1 << uint((dy+1)*3+dx+1)
+			}
+		}
+	}
+
+	return code
+}
+
+// match returns the pattern around a pixel off the border.
+func (set *patternSet) match(netMap []int32, width, x, y int) (Pattern, bool) {
+	index := set.table[patternCode(netMap, width, x, y)]
+	if index < 0 {
+		return Pattern{}, false
+	}
+
+	return set.patterns[index], true
+}
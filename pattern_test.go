@@ -0,0 +1,118 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestRegisterPattern(t *testing.T) {
+	defer gobls.ResetPatterns()
+
+	buffer := gobls.Pattern{
+		Name: "buffer right",
+		Kind: gobls.PATTERN_BUFFER,
+		In:   gobls.Pin{X: -1, Y: 0},
+		Out:  gobls.Pin{X: 1, Y: 0},
+		Dir:  gobls.DIR_RIGHT,
+		Kernel: [3]string{
+			"##.",
+			"#.#",
+			"#..",
+		},
+	}
+	err := gobls.RegisterPattern(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img := asciiImage(
+		".......",
+		"..##...",
+		"###.###",
+		"..#....",
+		".......",
+	)
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(img)
+
+	for _, state := range []bool{true, false, true} {
+		for i := 0; i < 10; i++ {
+			simulator.Set(0, 2, state)
+			simulator.Simulate()
+		}
+		if simulator.Get(6, 2) != state {
+			t.Errorf("buffer output %v for input %v", simulator.Get(6, 2), state)
+		}
+	}
+
+	// matches the right pointing NOT gate
+	overlapping := buffer
+	overlapping.Kernel = [3]string{"#?.", "#.#", "#?."}
+	if gobls.RegisterPattern(overlapping) == nil {
+		t.Error("overlapping pattern registered")
+	}
+
+	invalid := buffer
+	invalid.Out = gobls.Pin{X: 0, Y: 1}
+	if gobls.RegisterPattern(invalid) == nil {
+		t.Error("pattern with an insulating output registered")
+	}
+
+	gobls.ResetPatterns()
+	if len(gobls.Patterns()) != len(gobls.DefaultPatterns()) {
+		t.Errorf("%d patterns after reset", len(gobls.Patterns()))
+	}
+
+	simulator.LoadImage(img)
+	simulator.Set(0, 2, true)
+	for i := 0; i < 10; i++ {
+		simulator.Simulate()
+	}
+	if simulator.Get(6, 2) {
+		t.Error("output driven without the buffer pattern")
+	}
+}
+
+func TestRegisterPatternWhileLoading(t *testing.T) {
+	defer gobls.ResetPatterns()
+
+	// tall enough to be labeled in several bands in parallel
+	rows := []string{
+		"##...",
+		"#.###",
+		"##...",
+	}
+	for len(rows) < 1000 {
+		rows = append(rows, "#.#.#")
+	}
+	img := asciiImage(rows...)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			gobls.RegisterPattern(gobls.Pattern{
+				Name:   "buffer down",
+				Kind:   gobls.PATTERN_BUFFER,
+				In:     gobls.Pin{X: 0, Y: -1},
+				Out:    gobls.Pin{X: 0, Y: 1},
+				Dir:    gobls.DIR_DOWN,
+				Kernel: [3]string{"?#?", "..#", "?#?"},
+			})
+			gobls.ResetPatterns()
+		}
+	}()
+
+	simulator := gobls.NewSimulator()
+	for i := 0; i < 20; i++ {
+		simulator.LoadImage(img)
+		for j := 0; j < 10; j++ {
+			simulator.Simulate()
+		}
+		if !simulator.Get(4, 1) {
+			t.Fatal("NOT gate output low for a low input")
+		}
+	}
+	<-done
+}
@@ -0,0 +1,181 @@
+package gobls
+
+// satSolver is a small DPLL solver with two watched literals per clause.
+// Literals are 2*variable for the positive and 2*variable+1 for the
+// negative literal.
+type satSolver struct {
+	clauses [][]int
+	units   []int
+	empty   bool // an empty clause was added
+
+	watches [][]int // literal -> clauses watching it
+	values  []int8  // variable -> 0 unassigned, 1 true, -1 false
+
+	trail   []int  // assigned literals in order
+	head    int    // next trail entry to propagate
+	levels  []int  // trail position of each decision
+	flipped []bool // decision of the level was already tried both ways
+	next    int    // no variable below next is unassigned
+}
+
+func newSatSolver(variables int) *satSolver {
+	solver := new(satSolver)
+	solver.watches = make([][]int, variables*2)
+	solver.values = make([]int8, variables)
+
+	return solver
+}
+
+func satLit(variable int, negative bool) int {
+	if negative {
+		return variable<<1 | 1
+	}
+	return variable << 1
+}
+
+func (solver *satSolver) addClause(lits ...int) {
+	switch len(lits) {
+	case 0:
+		solver.empty = true
+	case 1:
+		solver.units = append(solver.units, lits[0])
+	default:
+		clause := append([]int(nil), lits...)
+		index := len(solver.clauses)
+		solver.clauses = append(solver.clauses, clause)
+		solver.watches[clause[0]] = append(solver.watches[clause[0]], index)
+		solver.watches[clause[1]] = append(solver.watches[clause[1]], index)
+	}
+}
+
+// value returns 1 when the literal is true, -1 when false, 0 when unassigned.
+func (solver *satSolver) value(lit int) int8 {
+	value := solver.values[lit>>1]
+	if lit&1 != 0 {
+		return -value
+	}
+	return value
+}
+
+func (solver *satSolver) assign(lit int) {
+	if lit&1 != 0 {
+		solver.values[lit>>1] = -1
+	} else {
+		solver.values[lit>>1] = 1
+	}
+	solver.trail = append(solver.trail, lit)
+}
+
+// propagate assigns the literals implied by unit clauses. It returns false
+// on a conflict.
+func (solver *satSolver) propagate() bool {
+	for solver.head < len(solver.trail) {
+		falseLit := solver.trail[solver.head] ^ 1
+		solver.head++
+
+		watches := solver.watches[falseLit]
+		kept := watches[:0]
+		for i, index := range watches {
+			clause := solver.clauses[index]
+			if clause[0] == falseLit {
+				clause[0], clause[1] = clause[1], clause[0]
+			}
+
+			if solver.value(clause[0]) == 1 {
+				kept = append(kept, index)
+				continue
+			}
+
+			// look for another literal to watch
+			moved := false
+			for k := 2; k < len(clause); k++ {
+				if solver.value(clause[k]) != -1 {
+					clause[1], clause[k] = clause[k], clause[1]
+					solver.watches[clause[1]] = append(solver.watches[clause[1]], index)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+
+			kept = append(kept, index)
+			if solver.value(clause[0]) == -1 {
+				kept = append(kept, watches[i+1:]...)
+				solver.watches[falseLit] = kept
+				return false
+			}
+			solver.assign(clause[0])
+		}
+		solver.watches[falseLit] = kept
+	}
+
+	return true
+}
+
+// undo unassigns the trail from the given position on.
+func (solver *satSolver) undo(from int) {
+	for _, lit := range solver.trail[from:] {
+		variable := lit >> 1
+		solver.values[variable] = 0
+		if variable < solver.next {
+			solver.next = variable
+		}
+	}
+	solver.trail = solver.trail[:from]
+	solver.head = from
+}
+
+// solve reports whether the clauses are satisfiable. Variables are decided
+// in index order, false first. On success values holds a model.
+func (solver *satSolver) solve() bool {
+	if solver.empty {
+		return false
+	}
+
+	for _, unit := range solver.units {
+		switch solver.value(unit) {
+		case -1:
+			return false
+		case 0:
+			solver.assign(unit)
+		}
+	}
+
+	for {
+		if !solver.propagate() {
+			// backtrack to the latest decision not tried both ways
+			for {
+				level := len(solver.levels) - 1
+				if level < 0 {
+					return false
+				}
+
+				decision := solver.trail[solver.levels[level]]
+				solver.undo(solver.levels[level])
+
+				if !solver.flipped[level] {
+					solver.flipped[level] = true
+					solver.assign(decision ^ 1)
+					break
+				}
+
+				solver.levels = solver.levels[:level]
+				solver.flipped = solver.flipped[:level]
+			}
+			continue
+		}
+
+		for solver.next < len(solver.values) && solver.values[solver.next] != 0 {
+			solver.next++
+		}
+		if solver.next == len(solver.values) {
+			return true
+		}
+
+		solver.levels = append(solver.levels, len(solver.trail))
+		solver.flipped = append(solver.flipped, false)
+		solver.assign(satLit(solver.next, true))
+	}
+}
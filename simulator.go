@@ -18,19 +18,44 @@ const (
 )
 
 type Simulator struct {
-	prevImage image.Image
-	curImage  image.Image
+	DumpImages bool // write wireMap.png and gate.png on every load
+
+	curImage image.Image
 
 	width  int
 	height int
 
-	wireMap   [][]int
-	wireRemap []int
+	netMap   []int32 // net index per pixel, row by row, -1 for insulation
+	freeNets []int   // net indices without pixels left by UpdateImage
+
+	netPixels []int             // pixels per net, measured on the first NetAt
+	netBounds []image.Rectangle // bounding box per net
+
+	drivers, readers map[int][]int // gates per net, built on the first NetAt
+	gateCenters      map[point]int // gate per center, built on the first GateAt
 
 	states []bool // wire states
 
 	gates    []*gate // not gates
 	gatePerm []int   // permutation for not gates
+
+	crossings      []point
+	extractionTime time.Duration
+
+	devices []Device
+	steps   int // steps simulated since the image was loaded
+
+	netFaults  map[int]bool // stuck-at values of nets
+	gateFaults map[int]bool // stuck-at values of gate outputs
+
+	forces     map[Pin]force // forced pixels
+	forceSeq   int           // forces applied
+	forcedNets map[int]bool  // nets of the forced pixels
+
+	tracking    bool   // count toggles
+	netToggles  []int  // state changes per net
+	gateToggles []int  // state changes per gate
+	prevStates  []bool // net states of the previous step
 }
 
 func NewSimulator() *Simulator {
@@ -40,172 +65,55 @@ func NewSimulator() *Simulator {
 }
 
 func (simulator *Simulator) LoadImage(img image.Image) {
-	simulator.prevImage = simulator.curImage
-	simulator.curImage = img
-
-	width := img.Bounds().Max.X
-	height := img.Bounds().Max.Y
-
-	// search wires horizontally
-	wireMap := make([][]int, height)
-	for i := range wireMap {
-		wireMap[i] = make([]int, width)
-	}
-
-	wireIdx := -1
-	for y := 0; y < height; y++ {
-		pixel := img.At(0, y)
-
-		if isConductive(pixel) {
-			wireIdx++
-
-			wireMap[y][0] = wireIdx
-		} else {
-			wireMap[y][0] = -1
-		}
-
-		for x := 1; x < width; x++ {
-			prevPixel := img.At(x-1, y)
-			curPixel := img.At(x, y)
-
-			if isConductive(curPixel) {
-				if !isConductive(prevPixel) {
-					wireIdx++
-				}
-
-				wireMap[y][x] = wireIdx
-			} else {
-				wireMap[y][x] = -1
-			}
-		}
-	}
-
-	// remap wires
-	wireRemap := make([]int, wireIdx+1)
-	for i := range wireRemap {
-		wireRemap[i] = i
-	}
+	start := time.Now()
 
-	for y := 1; y < height; y++ {
-		for x := 0; x < width; x++ {
-			upperWire := wireMap[y-1][x]
-			lowerWire := wireMap[y][x]
-
-			if upperWire >= 0 && lowerWire >= 0 {
-				upperIdx := wireRemap[upperWire]
-				lowerIdx := wireRemap[lowerWire]
-				if upperIdx != lowerIdx {
-					// connect two wire
-					for i, v := range wireRemap {
-						if v == lowerIdx {
-							wireRemap[i] = upperIdx
-						}
-					}
-				}
-			}
-		}
-	}
-
-	// search crossing wires and not gates
-	gates := make([]*gate, 0)
-	for y := 1; y < height-1; y++ {
-		for x := 1; x < width-1; x++ {
-			if wireMap[y][x] < 0 && wireMap[y][x-1] >= 0 && wireMap[y][x+1] >= 0 && wireMap[y-1][x] >= 0 && wireMap[y+1][x] >= 0 {
-				flag := 0
-
-				if wireMap[y-1][x-1] >= 0 {
-					flag |= 1 << 0
-				}
-				if wireMap[y-1][x+1] >= 0 {
-					flag |= 1 << 1
-				}
-				if wireMap[y+1][x+1] >= 0 {
-					flag |= 1 << 2
-				}
-				if wireMap[y+1][x-1] >= 0 {
-					flag |= 1 << 3
-				}
+	simulator.curImage = img
 
-				switch flag {
-				case 0: // crossing wire
-					// connect up, down wire and left, right wire
-					upperIdx := wireRemap[wireMap[y-1][x]]
-					lowerIdx := wireRemap[wireMap[y+1][x]]
-					leftIdx := wireRemap[wireMap[y][x-1]]
-					rightIdx := wireRemap[wireMap[y][x+1]]
-
-					for i, v := range wireRemap {
-						if v == lowerIdx {
-							wireRemap[i] = upperIdx
-						}
-					}
-					for i, v := range wireRemap {
-						if v == rightIdx {
-							wireRemap[i] = leftIdx
-						}
-					}
-				case 1 + 2: // not gate down
-					gates = append(gates, &gate{in: point{x, y - 1}, out: point{x, y + 1}})
-				case 2 + 4: // not gate left
-					gates = append(gates, &gate{in: point{x + 1, y}, out: point{x - 1, y}})
-				case 4 + 8: // not gate up
-					gates = append(gates, &gate{in: point{x, y + 1}, out: point{x, y - 1}})
-				case 8 + 1: // not gate right
-					gates = append(gates, &gate{in: point{x - 1, y}, out: point{x + 1, y}})
-				}
-			}
-		}
-	}
+	e := extractNets(img)
+	gates := e.gates
 
-	// resolve gate in, out idx
-	for _, gate := range gates {
-		gate.inIdx = wireRemap[wireMap[gate.in.y][gate.in.x]]
-		gate.outIdx = wireRemap[wireMap[gate.out.y][gate.out.x]]
+	// gates driving the input of every gate
+	drivers := make(map[int][]int)
+	for gateIdx, g := range gates {
+		drivers[g.outIdx] = append(drivers[g.outIdx], gateIdx)
 	}
-
-	// find input gates
-	for _, gate := range gates {
-		gate.inGates = make([]int, 0)
-		for gateIdx, inputGate := range gates {
-			if gate.inIdx == inputGate.outIdx {
-				gate.inGates = append(gate.inGates, gateIdx)
-			}
-		}
+	for _, g := range gates {
+		g.inGates = drivers[g.inIdx]
 	}
 
 	// gate permutation
 	gatePerm := rand.Perm(len(gates))
 
 	// init wire state
-	states := make([]bool, len(wireRemap))
-	for i := range states {
-		states[i] = false
-	}
-
-	// copy previous states
-	/*
-		if simulator.prevImage != nil {
-			for y := 0; y < height; y++ {
-				for x := 0; x < width; x++ {
-					if wireMap[y][x] != 0 && isConductive(simulator.prevImage.At(x, y)) {
-						states[wireRemap[wireMap[y][x]]] = true
-					}
-				}
-			}
-		}
-	*/
-
-	simulator.width = width
-	simulator.height = height
-	simulator.wireMap = wireMap
-	simulator.wireRemap = wireRemap
+	states := make([]bool, e.nets)
+
+	simulator.width = e.width
+	simulator.height = e.height
+	simulator.netMap = e.netMap
+	simulator.freeNets = nil
+	simulator.netPixels = nil
+	simulator.netBounds = nil
+	simulator.drivers = nil
+	simulator.readers = nil
+	simulator.gateCenters = nil
 	simulator.gates = gates
+	simulator.crossings = e.crossings
 	simulator.states = states
 	simulator.gatePerm = gatePerm
-
-	simulator.Simulate()
-
-	simulator.test()
+	simulator.steps = 0
+	simulator.extractionTime = time.Since(start)
+	simulator.ClearFaults()
+	simulator.updateForcedNets()
+
+	// settle without counting toggles, counters start over for the new image
+	tracking := simulator.tracking
+	simulator.tracking = false
+	simulator.simulateGates()
+	simulator.TrackActivity(tracking)
+
+	if simulator.DumpImages {
+		simulator.test()
+	}
 }
 
 func (simulator *Simulator) test() {
@@ -223,10 +131,8 @@ func (simulator *Simulator) test() {
 
 	for y := 0; y < simulator.height; y++ {
 		for x := 0; x < simulator.width; x++ {
-			wire := simulator.wireMap[y][x]
-			if wire >= 0 {
-				idx := simulator.wireRemap[wire]
-
+			idx := simulator.wireAt(x, y)
+			if idx >= 0 {
 				wireRemapImg.Set(x, y, color.RGBA{
 					uint8(randomRColor[idx%200] + 55),
 					uint8(randomGColor[idx%200] + 55),
@@ -262,20 +168,64 @@ func (simulator *Simulator) test() {
 }
 
 func (simulator *Simulator) Simulate() {
+	for _, device := range simulator.devices {
+		device.Step(simulator)
+	}
+
+	simulator.simulateGates()
+
+	if simulator.tracking {
+		simulator.countToggles()
+	}
+
+	simulator.steps++
+}
+
+// Steps returns the number of Simulate calls since the image was loaded.
+func (simulator *Simulator) Steps() int {
+	return simulator.steps
+}
+
+func (simulator *Simulator) simulateGates() {
+	faulty := len(simulator.gateFaults) > 0
+
 	for i := range simulator.gates {
-		g := simulator.gates[simulator.gatePerm[i]]
-		newState := !simulator.gateInput(g)
-		g.updateState(newState)
+		index := simulator.gatePerm[i]
+		g := simulator.gates[index]
+
+		if faulty {
+			if value, ok := simulator.gateFaults[index]; ok {
+				g.setState(value)
+				continue
+			}
+		}
+
+		newState := simulator.gateInput(g) == g.buffer
+		if simulator.tracking {
+			old := g.state
+			g.updateState(newState)
+			if g.state != old {
+				simulator.gateToggles[index]++
+			}
+		} else {
+			g.updateState(newState)
+		}
 	}
 
 	simulator.storeGateStatesToWires()
 }
 
 func (simulator *Simulator) Set(x, y int, state bool) bool {
-	wire := simulator.wireMap[y][x]
+	if !simulator.inBounds(x, y) {
+		return false
+	}
 
-	if wire >= 0 {
-		wireIdx := simulator.wireRemap[wire]
+	wireIdx := simulator.wireAt(x, y)
+
+	if wireIdx >= 0 {
+		if _, ok := simulator.netOverride(wireIdx); ok {
+			return true
+		}
 		simulator.states[wireIdx] = state
 
 		return true
@@ -285,10 +235,13 @@ func (simulator *Simulator) Set(x, y int, state bool) bool {
 }
 
 func (simulator *Simulator) Get(x, y int) bool {
-	wire := simulator.wireMap[y][x]
+	if !simulator.inBounds(x, y) {
+		return false
+	}
+
+	wireIdx := simulator.wireAt(x, y)
 
-	if wire >= 0 {
-		wireIdx := simulator.wireRemap[wire]
+	if wireIdx >= 0 {
 		state := simulator.states[wireIdx]
 
 		return state
@@ -297,30 +250,78 @@ func (simulator *Simulator) Get(x, y int) bool {
 	return false
 }
 
+// Image returns the currently loaded image.
+func (simulator *Simulator) Image() image.Image {
+	return simulator.curImage
+}
+
+// wireAt returns the net index under a pixel, -1 for insulation.
+func (simulator *Simulator) wireAt(x, y int) int {
+	if !simulator.inBounds(x, y) {
+		return -1
+	}
+
+	return int(simulator.netMap[y*simulator.width+x])
+}
+
+func (simulator *Simulator) inBounds(x, y int) bool {
+	return 0 <= x && x < simulator.width && 0 <= y && y < simulator.height
+}
+
 func (simulator *Simulator) Size() (int, int) {
 	return simulator.width, simulator.height
 }
 
 func (simulator *Simulator) PerPixel(f func(int, int, bool)) {
+	i := 0
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.netMap[i]
+			f(x, y, wire >= 0 && simulator.states[wire])
+			i++
+		}
+	}
+}
+
+// StateImage renders the net states, high pixels white, low ones gray and
+// insulation black.
+func (simulator *Simulator) StateImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, simulator.width, simulator.height))
+
+	i := 0
 	for y := 0; y < simulator.height; y++ {
 		for x := 0; x < simulator.width; x++ {
-			wire := simulator.wireMap[y][x]
-			state := false
-			if wire != -1 {
-				state = simulator.states[simulator.wireRemap[wire]]
+			c := color.RGBA{0, 0, 0, 255}
+			if wire := simulator.netMap[i]; wire >= 0 {
+				c = color.RGBA{64, 64, 64, 255}
+				if simulator.states[wire] {
+					c = color.RGBA{255, 255, 255, 255}
+				}
 			}
-			f(x, y, state)
+			img.SetRGBA(x, y, c)
+			i++
 		}
 	}
+
+	return img
 }
 
 func isConductive(pixel color.Color) bool {
-	r, g, b, _ := pixel.RGBA()
+	return bright(pixel.RGBA())
+}
 
+// bright tells if the premultiplied color channels make a pixel conductive.
+func bright(r, g, b, _ uint32) bool {
 	return r > BRIGHT_MIN || g > BRIGHT_MIN || b > BRIGHT_MIN
 }
 
 func (simulator *Simulator) gateInput(g *gate) bool {
+	if len(simulator.netFaults) > 0 || len(simulator.forcedNets) > 0 {
+		if value, ok := simulator.netOverride(g.inIdx); ok {
+			return value
+		}
+	}
+
 	if g.inGates == nil || len(g.inGates) == 0 {
 		return simulator.states[g.inIdx]
 	} else {
@@ -350,4 +351,21 @@ func (simulator *Simulator) storeGateStatesToWires() {
 			simulator.states[g.outIdx] = true
 		}
 	}
+
+	for wire, value := range simulator.netFaults {
+		simulator.states[wire] = value
+	}
+	for wire, value := range simulator.forcedNets {
+		simulator.states[wire] = value
+	}
+}
+
+// netOverride returns the value a net is forced or stuck at. Forces win over
+// faults.
+func (simulator *Simulator) netOverride(wire int) (bool, bool) {
+	if value, ok := simulator.forcedNets[wire]; ok {
+		return value, true
+	}
+	value, ok := simulator.netFaults[wire]
+	return value, ok
 }
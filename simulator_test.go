@@ -3,6 +3,7 @@ package gobls_test
 import (
 	"github.com/rlj1202/go-BitmapLogicSimulator"
 	"image"
+	"image/color"
 	_ "image/png"
 	"os"
 	"testing"
@@ -24,3 +25,20 @@ func TestLoad(t *testing.T) {
 	simulator.LoadImage(img)
 	simulator.Simulate()
 }
+
+// asciiImage draws a circuit from rows of '#' (conductive) and '.' (insulation).
+func asciiImage(rows ...string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	return img
+}
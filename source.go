@@ -0,0 +1,175 @@
+package gobls
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+)
+
+const (
+	SOURCE_CLOCK    = iota // square wave
+	SOURCE_PULSE           // high for the first Length steps, power-on reset
+	SOURCE_CONSTANT        // always Value
+)
+
+const (
+	DEFAULT_CLOCK_PERIOD = 64
+	DEFAULT_PULSE_LENGTH = 16
+)
+
+// Pixels of these exact colors are picked up by ColorSources. All of them are
+// bright enough to be conductive.
+var (
+	SOURCE_CLOCK_COLOR = color.RGBA{255, 0, 255, 255}
+	SOURCE_PULSE_COLOR = color.RGBA{0, 255, 255, 255}
+	SOURCE_HIGH_COLOR  = color.RGBA{255, 255, 0, 255}
+	SOURCE_LOW_COLOR   = color.RGBA{0, 0, 255, 255}
+)
+
+// Source drives its pins with a signal computed from the step counter.
+// Like every device it is stepped before the gate sweep of Simulate.
+//
+// A clock is high while (step+Phase) mod Period is below Duty.
+type Source struct {
+	Mode int
+	Pins Bus
+
+	Period int
+	Duty   int
+	Phase  int
+	Length int
+	Value  bool
+}
+
+type sourceConfig struct {
+	Pins string
+
+	Period int
+	Duty   int // defaults to half the period
+	Phase  int
+	Length int
+}
+
+func init() {
+	RegisterDevice("clock", buildSource(SOURCE_CLOCK, false))
+	RegisterDevice("reset", buildSource(SOURCE_PULSE, true))
+	RegisterDevice("high", buildSource(SOURCE_CONSTANT, true))
+	RegisterDevice("low", buildSource(SOURCE_CONSTANT, false))
+}
+
+func buildSource(mode int, value bool) DeviceBuilder {
+	return func(manifest *Manifest, raw json.RawMessage) (Device, error) {
+		config := sourceConfig{}
+		err := json.Unmarshal(raw, &config)
+		if err != nil {
+			return nil, err
+		}
+
+		pins, err := manifest.Bus(config.Pins)
+		if err != nil {
+			return nil, err
+		}
+
+		switch mode {
+		case SOURCE_CLOCK:
+			return NewClock(pins, config.Period, config.Duty, config.Phase)
+		case SOURCE_PULSE:
+			return NewPulse(pins, config.Length)
+		default:
+			return NewConstant(pins, value), nil
+		}
+	}
+}
+
+// NewClock creates a square wave. A duty of 0 gives half the period.
+func NewClock(pins Bus, period, duty, phase int) (*Source, error) {
+	if period < 2 {
+		return nil, errors.New("clock period must be at least 2 steps")
+	}
+	if duty == 0 {
+		duty = period / 2
+	}
+	if duty < 0 || duty > period {
+		return nil, errors.New("clock duty must be between 0 and the period")
+	}
+
+	return &Source{Mode: SOURCE_CLOCK, Pins: pins, Period: period, Duty: duty, Phase: phase}, nil
+}
+
+func NewPulse(pins Bus, length int) (*Source, error) {
+	if length <= 0 {
+		return nil, errors.New("pulse length must be positive")
+	}
+
+	return &Source{Mode: SOURCE_PULSE, Pins: pins, Length: length}, nil
+}
+
+func NewConstant(pins Bus, value bool) *Source {
+	return &Source{Mode: SOURCE_CONSTANT, Pins: pins, Value: value}
+}
+
+// State returns the output at a step.
+func (source *Source) State(step int) bool {
+	switch source.Mode {
+	case SOURCE_CLOCK:
+		t := (step + source.Phase) % source.Period
+		if t < 0 {
+			t += source.Period
+		}
+		return t < source.Duty
+	case SOURCE_PULSE:
+		return step < source.Length
+	default:
+		return source.Value
+	}
+}
+
+func (source *Source) Step(simulator *Simulator) {
+	state := source.State(simulator.Steps())
+
+	for _, pin := range source.Pins {
+		simulator.Set(pin.X, pin.Y, state)
+	}
+}
+
+// Reset does nothing, the signal follows the step counter.
+func (source *Source) Reset() {
+}
+
+// ColorSources finds pixels painted with the reserved source colors and
+// returns one source per color found, using the default clock period and
+// pulse length.
+func ColorSources(img image.Image) []*Source {
+	clock := &Source{Mode: SOURCE_CLOCK, Period: DEFAULT_CLOCK_PERIOD, Duty: DEFAULT_CLOCK_PERIOD / 2}
+	pulse := &Source{Mode: SOURCE_PULSE, Length: DEFAULT_PULSE_LENGTH}
+	high := NewConstant(nil, true)
+	low := NewConstant(nil, false)
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+
+			switch pixel {
+			case SOURCE_CLOCK_COLOR:
+				clock.Pins = append(clock.Pins, Pin{x, y})
+			case SOURCE_PULSE_COLOR:
+				pulse.Pins = append(pulse.Pins, Pin{x, y})
+			case SOURCE_HIGH_COLOR:
+				high.Pins = append(high.Pins, Pin{x, y})
+			case SOURCE_LOW_COLOR:
+				low.Pins = append(low.Pins, Pin{x, y})
+			}
+		}
+	}
+
+	sources := make([]*Source, 0)
+	for _, source := range []*Source{clock, pulse, high, low} {
+		if len(source.Pins) > 0 {
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
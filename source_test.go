@@ -0,0 +1,49 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestClockThroughNotGate(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(
+		"...........",
+		"....##.....",
+		".####.####.",
+		"....##.....",
+		"...........",
+	))
+
+	clock, err := gobls.NewClock(gobls.Bus{{1, 2}}, 20, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	simulator.Attach(clock)
+
+	for step := 0; step < 60; step++ {
+		simulator.Simulate()
+
+		// allow the gate two steps to follow an edge
+		if step%10 < 2 {
+			continue
+		}
+		if in, out := simulator.Get(1, 2), simulator.Get(8, 2); in == out {
+			t.Fatalf("step %d: in = %v, out = %v", step, in, out)
+		}
+		if simulator.Get(1, 2) != clock.State(step) {
+			t.Fatalf("step %d: clock not driven", step)
+		}
+	}
+}
+
+func TestColorSources(t *testing.T) {
+	img := asciiImage("#.#")
+	img.Set(2, 0, gobls.SOURCE_HIGH_COLOR)
+
+	sources := gobls.ColorSources(img)
+	if len(sources) != 1 || sources[0].Mode != gobls.SOURCE_CONSTANT || !sources[0].Value {
+		t.Fatalf("unexpected sources %+v", sources)
+	}
+}
@@ -0,0 +1,235 @@
+package gobls
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+type Stats struct {
+	Width, Height    int
+	ConductivePixels int
+	Nets             int
+	Crossings        int
+	Gates            int
+	GatesUp          int
+	GatesRight       int
+	GatesDown        int
+	GatesLeft        int
+
+	FanIn  map[int]int // gates driving a gate's input -> number of gates
+	FanOut map[int]int // gates reading a gate's output -> number of gates
+
+	LargestNet       Pin // a pixel of the net with the most pixels
+	LargestNetPixels int
+
+	FeedbackLoops int // strongly connected gate groups with a cycle
+
+	ExtractionTime time.Duration
+}
+
+func (simulator *Simulator) Stats() *Stats {
+	stats := new(Stats)
+	stats.Width = simulator.width
+	stats.Height = simulator.height
+	stats.Crossings = len(simulator.crossings)
+	stats.Gates = len(simulator.gates)
+	stats.ExtractionTime = simulator.extractionTime
+
+	// nets
+	pixels := make([]int, len(simulator.states))
+	first := make([]Pin, len(simulator.states))
+	for y := 0; y < simulator.height; y++ {
+		for x := 0; x < simulator.width; x++ {
+			wire := simulator.wireAt(x, y)
+			if wire < 0 {
+				continue
+			}
+
+			stats.ConductivePixels++
+			if pixels[wire] == 0 {
+				first[wire] = Pin{x, y}
+			}
+			pixels[wire]++
+		}
+	}
+	for wire, count := range pixels {
+		if count == 0 {
+			continue
+		}
+
+		stats.Nets++
+		if count > stats.LargestNetPixels || (count == stats.LargestNetPixels && pinLess(first[wire], stats.LargestNet)) {
+			stats.LargestNetPixels = count
+			stats.LargestNet = first[wire]
+		}
+	}
+
+	// gates
+	readers := simulator.netReaders()
+	stats.FanIn = make(map[int]int)
+	stats.FanOut = make(map[int]int)
+	for _, g := range simulator.gates {
+		switch g.dir {
+		case DIR_UP:
+			stats.GatesUp++
+		case DIR_RIGHT:
+			stats.GatesRight++
+		case DIR_DOWN:
+			stats.GatesDown++
+		case DIR_LEFT:
+			stats.GatesLeft++
+		}
+
+		stats.FanIn[len(g.inGates)]++
+		stats.FanOut[len(readers[g.outIdx])]++
+	}
+
+	stats.FeedbackLoops = simulator.countFeedbackLoops(readers)
+
+	return stats
+}
+
+func (stats *Stats) Write(w io.Writer) {
+	fmt.Fprintf(w, "size              %dx%d\n", stats.Width, stats.Height)
+	fmt.Fprintf(w, "conductive pixels %d\n", stats.ConductivePixels)
+	fmt.Fprintf(w, "nets              %d\n", stats.Nets)
+	fmt.Fprintf(w, "crossings         %d\n", stats.Crossings)
+	fmt.Fprintf(w, "gates             %d (up %d, right %d, down %d, left %d)\n",
+		stats.Gates, stats.GatesUp, stats.GatesRight, stats.GatesDown, stats.GatesLeft)
+	fmt.Fprintf(w, "largest net       %d pixels at %d,%d\n", stats.LargestNetPixels, stats.LargestNet.X, stats.LargestNet.Y)
+	fmt.Fprintf(w, "feedback loops    %d\n", stats.FeedbackLoops)
+	fmt.Fprintf(w, "extraction time   %v\n", stats.ExtractionTime)
+
+	writeHistogram(w, "fan-in", stats.FanIn)
+	writeHistogram(w, "fan-out", stats.FanOut)
+}
+
+func writeHistogram(w io.Writer, name string, histogram map[int]int) {
+	keys := make([]int, 0, len(histogram))
+	for key := range histogram {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	fmt.Fprintf(w, "%s\n", name)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %4d: %d\n", key, histogram[key])
+	}
+}
+
+// netReaders maps nets to the indices of the gates reading them.
+func (simulator *Simulator) netReaders() map[int][]int {
+	readers := make(map[int][]int)
+	for i, g := range simulator.gates {
+		readers[g.inIdx] = append(readers[g.inIdx], i)
+	}
+
+	return readers
+}
+
+// netDrivers maps nets to the indices of the gates driving them.
+func (simulator *Simulator) netDrivers() map[int][]int {
+	drivers := make(map[int][]int)
+	for i, g := range simulator.gates {
+		drivers[g.outIdx] = append(drivers[g.outIdx], i)
+	}
+
+	return drivers
+}
+
+// countFeedbackLoops counts the strongly connected components of the gate
+// graph that contain a cycle, using an iterative Tarjan's algorithm.
+func (simulator *Simulator) countFeedbackLoops(readers map[int][]int) int {
+	count := len(simulator.gates)
+	index := make([]int, count)
+	low := make([]int, count)
+	onStack := make([]bool, count)
+	for i := range index {
+		index[i] = -1
+	}
+
+	type frame struct {
+		gate int
+		next int
+	}
+
+	loops := 0
+	counter := 0
+	stack := make([]int, 0)
+
+	for root := range simulator.gates {
+		if index[root] >= 0 {
+			continue
+		}
+
+		calls := []frame{{root, 0}}
+		index[root] = counter
+		low[root] = counter
+		counter++
+		stack = append(stack, root)
+		onStack[root] = true
+
+		for len(calls) > 0 {
+			top := &calls[len(calls)-1]
+			fanout := readers[simulator.gates[top.gate].outIdx]
+
+			if top.next < len(fanout) {
+				next := fanout[top.next]
+				top.next++
+
+				if index[next] < 0 {
+					index[next] = counter
+					low[next] = counter
+					counter++
+					stack = append(stack, next)
+					onStack[next] = true
+					calls = append(calls, frame{next, 0})
+				} else if onStack[next] && index[next] < low[top.gate] {
+					low[top.gate] = index[next]
+				}
+				continue
+			}
+
+			gate := top.gate
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				parent := calls[len(calls)-1].gate
+				if low[gate] < low[parent] {
+					low[parent] = low[gate]
+				}
+			}
+
+			if low[gate] != index[gate] {
+				continue
+			}
+
+			// pop the component
+			size := 0
+			for {
+				member := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[member] = false
+				size++
+				if member == gate {
+					break
+				}
+			}
+
+			g := simulator.gates[gate]
+			if size > 1 || g.inIdx == g.outIdx {
+				loops++
+			}
+		}
+	}
+
+	return loops
+}
+
+func pinLess(a, b Pin) bool {
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.X < b.X
+}
@@ -0,0 +1,41 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+	"github.com/rlj1202/go-BitmapLogicSimulator/builder"
+)
+
+func TestStats(t *testing.T) {
+	// ring oscillator of three gates plus a crossing
+	b := builder.New(16, 12)
+	b.Not(3, 2, gobls.DIR_RIGHT)
+	b.Not(6, 2, gobls.DIR_RIGHT)
+	b.Not(9, 2, gobls.DIR_RIGHT)
+	b.Wire(10, 2, 12, 5)
+	b.Wire(12, 5, 2, 5)
+	b.Wire(2, 5, 2, 2)
+	b.Crossing(6, 9)
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(b.Image())
+	stats := simulator.Stats()
+
+	if stats.Gates != 3 || stats.GatesRight != 3 {
+		t.Errorf("gates = %d, right = %d", stats.Gates, stats.GatesRight)
+	}
+	if stats.Crossings != 1 {
+		t.Errorf("crossings = %d", stats.Crossings)
+	}
+	if stats.FeedbackLoops != 1 {
+		t.Errorf("feedback loops = %d", stats.FeedbackLoops)
+	}
+	if stats.FanIn[1] != 3 || stats.FanOut[1] != 3 {
+		t.Errorf("fan-in %v, fan-out %v", stats.FanIn, stats.FanOut)
+	}
+	// the loop's three nets and the crossing's two wires
+	if stats.Nets != 5 {
+		t.Errorf("nets = %d", stats.Nets)
+	}
+}
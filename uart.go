@@ -0,0 +1,274 @@
+package gobls
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+)
+
+const (
+	UART_INPUT_BUFFER = 256
+)
+
+// UART is a serial port speaking 8N1 frames. Bytes the circuit sends on TX
+// are written to Output and bytes read from Input are sent to the circuit
+// on RX. Period is the number of simulation steps per bit.
+//
+// Both lines idle high. The receiver ignores TX until it has seen it high
+// once, so the all-low power-on state is not taken as a start bit, and the
+// transmitter idles for a frame before sending the first byte.
+type UART struct {
+	TX     Bus
+	RX     Bus
+	Period int
+
+	Output io.Writer
+
+	input   chan byte
+	done    chan struct{} // closed by Close to stop the feeders
+	closed  bool
+	closers []io.Closer
+
+	// receiver
+	armed     bool
+	receiving bool
+	rxCount   int
+	rxBit     int
+	rxByte    byte
+
+	// transmitter
+	idle    int
+	sending bool
+	txCount int
+	txBit   int
+	txByte  byte
+}
+
+type uartConfig struct {
+	TX     string
+	RX     string
+	Period int
+
+	Output string // file name, "" or "-" for stdout
+	Input  string // file name, "-" for stdin, "" for none
+}
+
+func init() {
+	RegisterDevice("uart", buildUART)
+}
+
+func buildUART(manifest *Manifest, raw json.RawMessage) (Device, error) {
+	config := uartConfig{}
+	err := json.Unmarshal(raw, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := manifest.Bus(config.TX)
+	if err != nil {
+		return nil, err
+	}
+	rx, err := manifest.Bus(config.RX)
+	if err != nil {
+		return nil, err
+	}
+
+	uart, err := NewUART(tx, rx, config.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Output {
+	case "", "-":
+		uart.Output = os.Stdout
+	default:
+		file, err := os.Create(manifest.Path(config.Output))
+		if err != nil {
+			return nil, err
+		}
+		uart.Output = file
+		uart.closers = append(uart.closers, file)
+	}
+
+	switch config.Input {
+	case "":
+	case "-":
+		uart.Feed(os.Stdin)
+	default:
+		file, err := os.Open(manifest.Path(config.Input))
+		if err != nil {
+			uart.Close()
+			return nil, err
+		}
+		uart.Feed(file)
+		uart.closers = append(uart.closers, file)
+	}
+
+	return uart, nil
+}
+
+func NewUART(tx, rx Bus, period int) (*UART, error) {
+	if period < 2 {
+		return nil, errors.New("uart period must be at least 2 steps")
+	}
+
+	uart := new(UART)
+	uart.TX = tx
+	uart.RX = rx
+	uart.Period = period
+	uart.input = make(chan byte, UART_INPUT_BUFFER)
+	uart.done = make(chan struct{})
+	uart.idle = 10 * period
+
+	return uart, nil
+}
+
+// Feed queues every byte of r for sending on RX. Reading happens in the
+// background so a blocking reader such as stdin does not stall the simulation.
+// The reading stops when the UART is closed.
+func (uart *UART) Feed(r io.Reader) {
+	go func() {
+		buf := make([]byte, UART_INPUT_BUFFER)
+		for {
+			n, err := r.Read(buf)
+			for _, b := range buf[:n] {
+				select {
+				case uart.input <- b:
+				case <-uart.done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// Send queues a byte for sending on RX. It fails instead of waiting when the
+// queue is full, as only the simulation empties it.
+func (uart *UART) Send(b byte) error {
+	select {
+	case uart.input <- b:
+		return nil
+	default:
+		return errors.New("uart input buffer is full")
+	}
+}
+
+func (uart *UART) Close() error {
+	var firstErr error
+
+	if !uart.closed {
+		close(uart.done)
+		uart.closed = true
+	}
+
+	for _, closer := range uart.closers {
+		err := closer.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	uart.closers = nil
+
+	return firstErr
+}
+
+func (uart *UART) Step(simulator *Simulator) {
+	if len(uart.TX) > 0 {
+		uart.receive(simulator.Get(uart.TX[0].X, uart.TX[0].Y))
+	}
+	if len(uart.RX) > 0 {
+		simulator.Set(uart.RX[0].X, uart.RX[0].Y, uart.transmit())
+	}
+}
+
+func (uart *UART) receive(line bool) {
+	if !uart.receiving {
+		if line {
+			uart.armed = true
+		} else if uart.armed {
+			// falling edge, sample the start bit in its middle
+			uart.receiving = true
+			uart.rxCount = uart.Period / 2
+			uart.rxBit = -1
+			uart.rxByte = 0
+		}
+		return
+	}
+
+	uart.rxCount--
+	if uart.rxCount > 0 {
+		return
+	}
+	uart.rxCount = uart.Period
+
+	switch {
+	case uart.rxBit < 0: // start bit
+		if line {
+			// glitch
+			uart.receiving = false
+			return
+		}
+	case uart.rxBit < 8:
+		if line {
+			uart.rxByte |= 1 << uint(uart.rxBit)
+		}
+	default: // stop bit
+		uart.receiving = false
+		if !line {
+			// framing error, wait for the line to idle again
+			uart.armed = false
+			return
+		}
+		if uart.Output != nil {
+			uart.Output.Write([]byte{uart.rxByte})
+		}
+		return
+	}
+
+	uart.rxBit++
+}
+
+func (uart *UART) transmit() bool {
+	// hold the line high for a frame first so the circuit sees it idle
+	if uart.idle > 0 {
+		uart.idle--
+		return true
+	}
+
+	if !uart.sending {
+		select {
+		case b := <-uart.input:
+			uart.sending = true
+			uart.txByte = b
+			uart.txBit = -1
+			uart.txCount = uart.Period
+		default:
+			return true
+		}
+	}
+
+	var line bool
+	switch {
+	case uart.txBit < 0:
+		line = false
+	case uart.txBit < 8:
+		line = uart.txByte&(1<<uint(uart.txBit)) != 0
+	default:
+		line = true
+	}
+
+	uart.txCount--
+	if uart.txCount == 0 {
+		uart.txCount = uart.Period
+		uart.txBit++
+		if uart.txBit > 8 {
+			uart.sending = false
+		}
+	}
+
+	return line
+}
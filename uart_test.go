@@ -0,0 +1,66 @@
+package gobls_test
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestUARTLoopback(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage("#####"))
+
+	// transmitter and receiver share one wire
+	uart, err := gobls.NewUART(gobls.Bus{{0, 0}}, gobls.Bus{{4, 0}}, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := new(bytes.Buffer)
+	uart.Output = output
+	simulator.Attach(uart)
+
+	for _, b := range []byte("Hi") {
+		if err := uart.Send(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 8*10*4; i++ {
+		simulator.Simulate()
+	}
+
+	if output.String() != "Hi" {
+		t.Errorf("received %q, want %q", output.String(), "Hi")
+	}
+}
+
+func TestUARTClose(t *testing.T) {
+	uart, err := gobls.NewUART(gobls.Bus{{0, 0}}, gobls.Bus{{1, 0}}, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < gobls.UART_INPUT_BUFFER; i++ {
+		if err := uart.Send(0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if uart.Send(0) == nil {
+		t.Error("send to a full buffer did not fail")
+	}
+
+	// the feeder waits on the full buffer until the uart is closed
+	goroutines := runtime.NumGoroutine()
+	uart.Feed(strings.NewReader("more"))
+	uart.Close()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if runtime.NumGoroutine() > goroutines {
+		t.Error("feeder still running after close")
+	}
+}
@@ -0,0 +1,385 @@
+package gobls
+
+import (
+	"image"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// UPDATE_MAX_DIRTY is the fraction of the image the changed regions may
+// cover before UpdateImage extracts the whole image again.
+const UPDATE_MAX_DIRTY = 0.25
+
+// UpdateImage loads a new version of the loaded image. Only the gates,
+// crossings and nets around pixels whose conductivity changed are extracted
+// again; the other nets and gates keep their indices and states. An image of
+// another size is loaded with LoadImage. As with LoadImage, faults are
+// cleared and the activity counters start over unless no conductivity
+// changed. It returns the changed regions, the whole image after a full
+// load.
+func (simulator *Simulator) UpdateImage(img image.Image) []image.Rectangle {
+	return simulator.UpdateRegion(img, img.Bounds())
+}
+
+// UpdateRegion is UpdateImage for an image known to differ from the loaded
+// one only inside changed, such as after an edit. Only the pixels inside
+// changed are compared with the net map.
+func (simulator *Simulator) UpdateRegion(img image.Image, changed image.Rectangle) []image.Rectangle {
+	start := time.Now()
+
+	bounds := img.Bounds()
+	if simulator.netMap == nil || bounds.Max.X != simulator.width || bounds.Max.Y != simulator.height {
+		simulator.LoadImage(img)
+		return []image.Rectangle{image.Rect(0, 0, simulator.width, simulator.height)}
+	}
+
+	regions := simulator.dirtyRegions(img, changed.Intersect(image.Rect(0, 0, simulator.width, simulator.height)))
+
+	area := 0
+	for _, r := range regions {
+		area += r.Dx() * r.Dy()
+	}
+	if float64(area) > UPDATE_MAX_DIRTY*float64(simulator.width*simulator.height) {
+		simulator.LoadImage(img)
+		return []image.Rectangle{image.Rect(0, 0, simulator.width, simulator.height)}
+	}
+
+	simulator.curImage = img
+
+	if len(regions) == 0 {
+		return regions
+	}
+
+	simulator.extractRegions(img, regions)
+
+	// resolve gate in, out idx
+	drivers := make(map[int][]int)
+	for gateIdx, g := range simulator.gates {
+		g.inIdx = simulator.wireAt(g.in.x, g.in.y)
+		g.outIdx = simulator.wireAt(g.out.x, g.out.y)
+		drivers[g.outIdx] = append(drivers[g.outIdx], gateIdx)
+	}
+	for _, g := range simulator.gates {
+		g.inGates = drivers[g.inIdx]
+	}
+	simulator.drivers = nil
+	simulator.readers = nil
+	simulator.gateCenters = nil
+
+	simulator.gatePerm = rand.Perm(len(simulator.gates))
+	simulator.extractionTime = time.Since(start)
+	simulator.ClearFaults()
+	simulator.updateForcedNets()
+
+	tracking := simulator.tracking
+	simulator.tracking = false
+	simulator.simulateGates()
+	simulator.TrackActivity(tracking)
+
+	if simulator.DumpImages {
+		simulator.test()
+	}
+
+	return regions
+}
+
+// UPDATE_BAND_REGIONS is the number of separate changed regions a band
+// collects before they are joined into one.
+const UPDATE_BAND_REGIONS = 16
+
+// dirtyRegions compares the conductivity of an image of the same size with
+// the net map inside area and returns the bounding boxes of the changed
+// pixels, boxes closer than a pattern merged.
+func (simulator *Simulator) dirtyRegions(img image.Image, area image.Rectangle) []image.Rectangle {
+	width := simulator.width
+	if area.Empty() {
+		return make([]image.Rectangle, 0)
+	}
+
+	// bands overlapping the area
+	first := area.Min.Y / EXTRACT_BAND_HEIGHT
+	bands := (area.Max.Y+EXTRACT_BAND_HEIGHT-1)/EXTRACT_BAND_HEIGHT - first
+	boxes := make([][]image.Rectangle, bands)
+
+	read := conductiveRows(img)
+	parallel(bands, func(band int) {
+		y0 := (first + band) * EXTRACT_BAND_HEIGHT
+		y1 := y0 + EXTRACT_BAND_HEIGHT
+		if y0 < area.Min.Y {
+			y0 = area.Min.Y
+		}
+		if y1 > area.Max.Y {
+			y1 = area.Max.Y
+		}
+
+		row := make([]bool, width)
+		for y := y0; y < y1; y++ {
+			read(y, row)
+
+			nets := simulator.netMap[y*width : (y+1)*width]
+			for x := area.Min.X; x < area.Max.X; x++ {
+				if row[x] != (nets[x] >= 0) {
+					boxes[band] = addRegion(boxes[band], image.Rect(x, y, x+1, y+1))
+				}
+			}
+
+			if len(boxes[band]) > UPDATE_BAND_REGIONS {
+				box := image.Rectangle{}
+				for _, r := range boxes[band] {
+					box = box.Union(r)
+				}
+				boxes[band] = []image.Rectangle{box}
+			}
+		}
+	})
+
+	regions := make([]image.Rectangle, 0)
+	for _, band := range boxes {
+		for _, box := range band {
+			regions = addRegion(regions, box)
+		}
+	}
+
+	return regions
+}
+
+// addRegion adds a box to a list of regions, merging regions closer than a
+// pattern.
+func addRegion(regions []image.Rectangle, box image.Rectangle) []image.Rectangle {
+	for merged := true; merged; {
+		merged = false
+		for i, r := range regions {
+			if r.Inset(-2).Overlaps(box) {
+				box = box.Union(r)
+				regions = append(regions[:i], regions[i+1:]...)
+				merged = true
+				break
+			}
+		}
+	}
+
+	return append(regions, box)
+}
+
+// extractRegions writes the new conductivity of the changed regions into
+// the net map, matches the gates and crossings whose pattern overlaps them
+// again and relabels the nets touching them with a flood fill.
+func (simulator *Simulator) extractRegions(img image.Image, regions []image.Rectangle) {
+	width, height := simulator.width, simulator.height
+	netMap := simulator.netMap
+	patterns := currentPatterns()
+
+	// gate and crossing centers near a changed pixel, pixels whose net may
+	// change through a changed pixel or crossing
+	centers := make([]image.Rectangle, len(regions))
+	pixels := make([]image.Rectangle, len(regions))
+	for i, r := range regions {
+		centers[i] = r.Inset(-1).Intersect(image.Rect(1, 1, width-1, height-1))
+		pixels[i] = r.Inset(-2).Intersect(image.Rect(0, 0, width, height))
+	}
+	inside := func(rects []image.Rectangle, x, y int) bool {
+		for _, r := range rects {
+			if (image.Point{x, y}).In(r) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// nets to relabel, the extra one marks new conductive pixels
+	added := int32(len(simulator.states))
+	affected := make([]bool, len(simulator.states)+1)
+	affected[added] = true
+	for _, r := range pixels {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for _, net := range netMap[y*width+r.Min.X : y*width+r.Max.X] {
+				if net >= 0 {
+					affected[net] = true
+				}
+			}
+		}
+	}
+
+	read := conductiveRows(img)
+	row := make([]bool, width)
+	for _, r := range regions {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			read(y, row)
+			for x := r.Min.X; x < r.Max.X; x++ {
+				i := y*width + x
+				if row[x] && netMap[i] < 0 {
+					netMap[i] = added
+				} else if !row[x] && netMap[i] >= 0 {
+					netMap[i] = -1
+				}
+			}
+		}
+	}
+
+	// gates and crossings
+	gates := simulator.gates[:0]
+	for _, g := range simulator.gates {
+		if !inside(centers, g.at.x, g.at.y) {
+			gates = append(gates, g)
+		}
+	}
+	crossings := simulator.crossings[:0]
+	for _, p := range simulator.crossings {
+		if !inside(centers, p.x, p.y) {
+			crossings = append(crossings, p)
+		}
+	}
+
+	for i, r := range centers {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if netMap[y*width+x] >= 0 || inside(centers[:i], x, y) {
+					continue
+				}
+
+				pattern, ok := patterns.match(netMap, width, x, y)
+				if !ok {
+					continue
+				}
+
+				switch pattern.Kind {
+				case PATTERN_CROSSING:
+					crossings = append(crossings, point{x, y})
+				case PATTERN_NOT, PATTERN_BUFFER:
+					gates = append(gates, &gate{
+						at:     point{x, y},
+						in:     point{x + pattern.In.X, y + pattern.In.Y},
+						out:    point{x + pattern.Out.X, y + pattern.Out.Y},
+						dir:    pattern.Dir,
+						buffer: pattern.Kind == PATTERN_BUFFER,
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(gates, func(i, j int) bool {
+		return pointLess(gates[i].at, gates[j].at)
+	})
+	sort.SliceStable(crossings, func(i, j int) bool {
+		return pointLess(crossings[i], crossings[j])
+	})
+	simulator.gates = gates
+	simulator.crossings = crossings
+
+	// flood fill the affected nets from the pixels near the changes,
+	// numbering the new nets from base on
+	base := added + 1
+	visited := make([]int, 0)
+	states := make([]bool, 0)
+
+	dirs := [4]point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	for _, r := range pixels {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				net := netMap[y*width+x]
+				if net < 0 || net >= base || !affected[net] {
+					continue
+				}
+
+				label := base + int32(len(states))
+				state := false
+				push := func(i int) {
+					if old := netMap[i]; old != added && simulator.states[old] {
+						state = true
+					}
+					netMap[i] = label
+					visited = append(visited, i)
+				}
+
+				push(y*width + x)
+				for next := len(visited) - 1; next < len(visited); next++ {
+					px, py := visited[next]%width, visited[next]/width
+					for _, d := range dirs {
+						nx, ny := px+d.x, py+d.y
+						if nx < 0 || ny < 0 || nx >= width || ny >= height {
+							continue
+						}
+
+						n := netMap[ny*width+nx]
+						if n < 0 {
+							// through a crossing to the opposite arm
+							if nx < 1 || ny < 1 || nx >= width-1 || ny >= height-1 {
+								continue
+							}
+							pattern, ok := patterns.match(netMap, width, nx, ny)
+							if !ok || pattern.Kind != PATTERN_CROSSING {
+								continue
+							}
+							nx, ny = nx+d.x, ny+d.y
+							n = netMap[ny*width+nx]
+						}
+
+						if n >= 0 && n < base && affected[n] {
+							push(ny*width + nx)
+						}
+					}
+				}
+
+				states = append(states, state)
+			}
+		}
+	}
+
+	// reuse the indices of the replaced nets, then append
+	free := simulator.freeNets
+	for net, ok := range affected[:added] {
+		if ok {
+			free = append(free, net)
+		}
+	}
+	sort.Ints(free)
+
+	nets := make([]int32, len(states))
+	for i, state := range states {
+		net := len(simulator.states)
+		if i < len(free) {
+			net = free[i]
+		} else {
+			simulator.states = append(simulator.states, false)
+		}
+
+		nets[i] = int32(net)
+		simulator.states[net] = state
+	}
+	if len(states) < len(free) {
+		simulator.freeNets = free[len(states):]
+		for _, net := range simulator.freeNets {
+			simulator.states[net] = false
+		}
+	} else {
+		simulator.freeNets = nil
+	}
+
+	for _, i := range visited {
+		netMap[i] = nets[netMap[i]-base]
+	}
+
+	// keep the measures of NetAt up to date
+	if simulator.netPixels != nil {
+		for len(simulator.netPixels) < len(simulator.states) {
+			simulator.netPixels = append(simulator.netPixels, 0)
+			simulator.netBounds = append(simulator.netBounds, image.Rectangle{})
+		}
+		for _, net := range free {
+			simulator.netPixels[net] = 0
+			simulator.netBounds[net] = image.Rectangle{}
+		}
+		for _, i := range visited {
+			simulator.measurePixel(int(netMap[i]), i%width, i/width)
+		}
+	}
+}
+
+func pointLess(a, b point) bool {
+	if a.y != b.y {
+		return a.y < b.y
+	}
+	return a.x < b.x
+}
@@ -0,0 +1,144 @@
+package gobls_test
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestUpdateImage(t *testing.T) {
+	// random pixels make many nets, gates and crossings
+	random := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, 80, 600))
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if random.Intn(3) > 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(img)
+	simulator.NetAt(0, 0) // measures kept by the updates
+
+	for round := 0; round < 50; round++ {
+		next := image.NewRGBA(img.Rect)
+		copy(next.Pix, img.Pix)
+
+		for edit := random.Intn(3); edit >= 0; edit-- {
+			x, y := random.Intn(img.Rect.Dx()), random.Intn(img.Rect.Dy())
+			for i := random.Intn(6); i >= 0; i-- {
+				px, py := x+random.Intn(5), y+random.Intn(5)
+				if random.Intn(2) == 0 {
+					next.Set(px, py, color.White)
+				} else {
+					next.Set(px, py, color.Black)
+				}
+			}
+		}
+		img = next
+
+		regions := simulator.UpdateImage(img)
+		for _, r := range regions {
+			if r.Dx()*r.Dy() > 1000 {
+				t.Errorf("round %d: region %v", round, r)
+			}
+		}
+
+		fresh := gobls.NewSimulator()
+		fresh.LoadImage(img)
+
+		for _, difference := range gobls.Diff(simulator, fresh) {
+			t.Errorf("round %d: %v", round, difference)
+		}
+
+		for i := 0; i < 100; i++ {
+			x, y := random.Intn(img.Rect.Dx()), random.Intn(img.Rect.Dy())
+			a, _ := simulator.NetAt(x, y)
+			b, _ := fresh.NetAt(x, y)
+			if a.Pixels != b.Pixels || a.Bounds != b.Bounds || len(a.Drivers) != len(b.Drivers) || len(a.Readers) != len(b.Readers) {
+				t.Fatalf("round %d: net at %d,%d: %+v, want %+v", round, x, y, a, b)
+			}
+		}
+
+		a, b := simulator.Stats(), fresh.Stats()
+		if a.Nets != b.Nets || a.Gates != b.Gates || a.Crossings != b.Crossings || a.LargestNetPixels != b.LargestNetPixels {
+			t.Fatalf("round %d: nets %d/%d, gates %d/%d, crossings %d/%d", round, a.Nets, b.Nets, a.Gates, b.Gates, a.Crossings, b.Crossings)
+		}
+	}
+}
+
+func TestUpdateImageKeepsState(t *testing.T) {
+	rows := []string{
+		"##########",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+		"..........",
+		"####.#####",
+	}
+
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(rows...))
+	simulator.Set(0, 0, true)
+	simulator.Set(0, 6, true)
+
+	// join the two halves of the bottom wire
+	rows[6] = "##########"
+	regions := simulator.UpdateImage(asciiImage(rows...))
+	if len(regions) != 1 || regions[0] != image.Rect(4, 6, 5, 7) {
+		t.Errorf("regions %v", regions)
+	}
+
+	simulator.Simulate()
+	if !simulator.Get(0, 0) {
+		t.Error("state of an unchanged net lost")
+	}
+	if !simulator.Get(9, 6) {
+		t.Error("joined net does not keep the state of its parts")
+	}
+
+	if regions := simulator.UpdateImage(asciiImage(rows...)); len(regions) != 0 {
+		t.Errorf("regions %v for the same image", regions)
+	}
+}
+
+func TestUpdateRegion(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(
+		"#.#.....",
+		"........",
+		"........",
+		".....#.#",
+	))
+
+	// both gaps are closed, only the first one is inside the region
+	img := asciiImage(
+		"###.....",
+		"........",
+		"........",
+		".....###",
+	)
+	regions := simulator.UpdateRegion(img, image.Rect(0, 0, 4, 2))
+	if len(regions) != 1 || !regions[0].Eq(image.Rect(1, 0, 2, 1)) {
+		t.Errorf("regions %v, want [(1,0)-(2,1)]", regions)
+	}
+	if simulator.Set(0, 0, true); !simulator.Get(2, 0) {
+		t.Error("gap inside the region not closed")
+	}
+	if simulator.Set(5, 3, true); simulator.Get(7, 3) {
+		t.Error("gap outside the region closed")
+	}
+
+	simulator.UpdateImage(img)
+	if simulator.Set(5, 3, true); !simulator.Get(7, 3) {
+		t.Error("gap outside the region not closed by UpdateImage")
+	}
+}
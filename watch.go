@@ -0,0 +1,344 @@
+package gobls
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Watch is a condition over pin groups of a manifest, e.g. "pc == 0x40",
+// "halt rises" or "clk rises && we". A group compares as the number its pins
+// form, the first pin the lowest bit, and alone it is true when not zero.
+// "group[i]" is the i-th pin of a group. The edges rises, falls and changes
+// compare with the previous check.
+type Watch struct {
+	Expr string
+
+	root  watchNode
+	terms []*watchTerm
+	edges []watchNode // edge terms
+
+	last    bool // condition at the last check
+	checked bool
+}
+
+type watchNode interface {
+	eval() bool
+}
+
+// watchTerm is the value of a group, sampled at every check.
+type watchTerm struct {
+	name        string
+	bus         Bus
+	value, prev uint64
+}
+
+type watchTruth struct {
+	term *watchTerm
+}
+
+type watchCompare struct {
+	term  *watchTerm
+	op    string
+	value uint64
+}
+
+type watchEdge struct {
+	term *watchTerm
+	edge string
+}
+
+type watchNot struct {
+	node watchNode
+}
+
+type watchAnd struct {
+	a, b watchNode
+}
+
+type watchOr struct {
+	a, b watchNode
+}
+
+func (n watchTruth) eval() bool {
+	return n.term.value != 0
+}
+
+func (n watchCompare) eval() bool {
+	v := n.term.value
+	switch n.op {
+	case "==":
+		return v == n.value
+	case "!=":
+		return v != n.value
+	case "<":
+		return v < n.value
+	case "<=":
+		return v <= n.value
+	case ">":
+		return v > n.value
+	}
+	return v >= n.value
+}
+
+func (n watchEdge) eval() bool {
+	switch n.edge {
+	case "rises":
+		return n.term.prev == 0 && n.term.value != 0
+	case "falls":
+		return n.term.prev != 0 && n.term.value == 0
+	}
+	return n.term.prev != n.term.value
+}
+
+func (n watchNot) eval() bool {
+	return !n.node.eval()
+}
+
+// both sides are evaluated, no term is skipped
+func (n watchAnd) eval() bool {
+	a, b := n.a.eval(), n.b.eval()
+	return a && b
+}
+
+func (n watchOr) eval() bool {
+	a, b := n.a.eval(), n.b.eval()
+	return a || b
+}
+
+// NewWatch parses a condition and resolves its groups in a manifest.
+func NewWatch(expr string, manifest *Manifest) (*Watch, error) {
+	tokens, err := watchTokens(expr)
+	if err != nil {
+		return nil, fmt.Errorf("watch %q: %v", expr, err)
+	}
+
+	p := &watchParser{tokens: tokens, manifest: manifest, terms: make(map[string]*watchTerm)}
+	root, err := p.or()
+	if err == nil && p.pos < len(tokens) {
+		err = fmt.Errorf("unexpected %q", tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("watch %q: %v", expr, err)
+	}
+
+	return &Watch{Expr: expr, root: root, terms: p.order, edges: p.edges}, nil
+}
+
+// Check samples the groups and tells if the condition fired: when it became
+// true since the last check, or is true while one of its edges happens, as
+// an edge lasts a single check. The first check only samples.
+func (watch *Watch) Check(simulator *Simulator) bool {
+	for _, term := range watch.terms {
+		term.prev = term.value
+		term.value = simulator.ReadBus(term.bus)
+	}
+	if !watch.checked {
+		for _, term := range watch.terms {
+			term.prev = term.value
+		}
+	}
+
+	value := watch.root.eval()
+	edge := false
+	for _, node := range watch.edges {
+		edge = edge || node.eval()
+	}
+	fired := watch.checked && value && (edge || !watch.last)
+
+	watch.last = value
+	watch.checked = true
+
+	return fired
+}
+
+// Values lists the groups of the condition with their values at the last
+// check.
+func (watch *Watch) Values() string {
+	values := make([]string, len(watch.terms))
+	for i, term := range watch.terms {
+		if len(term.bus) == 1 {
+			values[i] = fmt.Sprintf("%s=%d", term.name, term.value)
+		} else {
+			values[i] = fmt.Sprintf("%s=0x%x", term.name, term.value)
+		}
+	}
+
+	return strings.Join(values, " ")
+}
+
+// watchTokens splits a condition into names, numbers and operators.
+func watchTokens(expr string) ([]string, error) {
+	tokens := make([]string, 0)
+
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune("_.-", runes[j])) {
+				j++
+			}
+			// a pin of the group
+			if j < len(runes) && runes[j] == '[' {
+				for j < len(runes) && runes[j] != ']' {
+					j++
+				}
+				if j == len(runes) {
+					return nil, fmt.Errorf("unclosed [ in %q", string(runes[i:]))
+				}
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			if i+1 < len(runes) {
+				if op := string(runes[i : i+2]); op == "==" || op == "!=" || op == "<=" || op == ">=" || op == "&&" || op == "||" {
+					tokens = append(tokens, op)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("<>!()", r) {
+				return nil, fmt.Errorf("unexpected %q", r)
+			}
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+
+	return tokens, nil
+}
+
+// watchParser parses tokens by precedence: || binds loosest, then &&, then
+// !, comparisons and edges.
+type watchParser struct {
+	tokens   []string
+	pos      int
+	manifest *Manifest
+
+	terms map[string]*watchTerm
+	order []*watchTerm
+	edges []watchNode
+}
+
+func (p *watchParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *watchParser) or() (watchNode, error) {
+	node, err := p.and()
+	for err == nil && p.peek() == "||" {
+		p.pos++
+		var b watchNode
+		b, err = p.and()
+		node = watchOr{node, b}
+	}
+
+	return node, err
+}
+
+func (p *watchParser) and() (watchNode, error) {
+	node, err := p.unary()
+	for err == nil && p.peek() == "&&" {
+		p.pos++
+		var b watchNode
+		b, err = p.unary()
+		node = watchAnd{node, b}
+	}
+
+	return node, err
+}
+
+func (p *watchParser) unary() (watchNode, error) {
+	switch p.peek() {
+	case "":
+		return nil, fmt.Errorf("unexpected end")
+	case "!":
+		p.pos++
+		node, err := p.unary()
+		return watchNot{node}, err
+	case "(":
+		p.pos++
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return node, nil
+	}
+
+	term, err := p.term(p.peek())
+	if err != nil {
+		return nil, err
+	}
+	p.pos++
+
+	switch op := p.peek(); op {
+	case "rises", "falls", "changes":
+		p.pos++
+		edge := watchEdge{term, op}
+		p.edges = append(p.edges, edge)
+		return edge, nil
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.pos++
+		value, err := strconv.ParseUint(p.peek(), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number after %s, got %q", op, p.peek())
+		}
+		p.pos++
+		return watchCompare{term, op, value}, nil
+	}
+
+	return watchTruth{term}, nil
+}
+
+// term resolves a group or a pin of a group, one term per name.
+func (p *watchParser) term(name string) (*watchTerm, error) {
+	if term, ok := p.terms[name]; ok {
+		return term, nil
+	}
+
+	group, index := name, -1
+	if open := strings.IndexByte(name, '['); open >= 0 {
+		group = name[:open]
+		i, err := strconv.Atoi(name[open+1 : len(name)-1])
+		if err == nil && i < 0 {
+			err = fmt.Errorf("negative")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pin index of %q: %v", name, err)
+		}
+		index = i
+	}
+
+	if !unicode.IsLetter([]rune(group)[0]) && group[0] != '_' {
+		return nil, fmt.Errorf("expected a pin group, got %q", name)
+	}
+	bus, err := p.manifest.Bus(group)
+	if err != nil {
+		return nil, err
+	}
+	if index >= 0 {
+		if index >= len(bus) {
+			return nil, fmt.Errorf("%q has %d pins", group, len(bus))
+		}
+		bus = bus[index : index+1]
+	}
+
+	term := &watchTerm{name: name, bus: bus}
+	p.terms[name] = term
+	p.order = append(p.order, term)
+
+	return term, nil
+}
@@ -0,0 +1,104 @@
+package gobls_test
+
+import (
+	"testing"
+
+	"github.com/rlj1202/go-BitmapLogicSimulator"
+)
+
+func TestWatch(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(
+		"#.#.#.#",
+		".......",
+		"#......",
+	))
+
+	manifest := gobls.NewManifest()
+	manifest.Pins["pc"] = gobls.Bus{{0, 0}, {2, 0}, {4, 0}, {6, 0}}
+	manifest.Pins["clk"] = gobls.Bus{{0, 2}}
+
+	steps := []struct {
+		pc   uint64
+		clk  bool
+		want []bool // pc == 0x5, clk rises && pc[0], !(pc < 4) || clk falls
+	}{
+		{0, false, []bool{false, false, false}},
+		{5, false, []bool{true, false, true}},
+		{5, true, []bool{false, true, false}},
+		{4, false, []bool{false, false, true}},
+		{5, false, []bool{true, false, false}},
+		{3, false, []bool{false, false, false}},
+		{3, true, []bool{false, true, false}},
+		{3, false, []bool{false, false, true}},
+	}
+
+	exprs := []string{"pc == 0x5", "clk rises && pc[0]", "!(pc < 4) || clk falls"}
+	watches := make([]*gobls.Watch, len(exprs))
+	for i, expr := range exprs {
+		watch, err := gobls.NewWatch(expr, manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		watches[i] = watch
+	}
+
+	for i, step := range steps {
+		simulator.WriteBus(manifest.Pins["pc"], step.pc)
+		simulator.Set(0, 2, step.clk)
+
+		for j, watch := range watches {
+			if fired := watch.Check(simulator); fired != step.want[j] {
+				t.Errorf("step %d: %q fired %v (%s)", i, watch.Expr, fired, watch.Values())
+			}
+		}
+	}
+
+	if values := watches[1].Values(); values != "clk=0 pc[0]=1" {
+		t.Errorf("values %q", values)
+	}
+
+	for _, expr := range []string{"", "pc ==", "pc == x", "nope", "pc[4]", "pc[-1]", "(pc", "pc pc", "pc & clk", "pc[0"} {
+		if _, err := gobls.NewWatch(expr, manifest); err == nil {
+			t.Errorf("%q parsed", expr)
+		}
+	}
+}
+
+func TestWatchEdges(t *testing.T) {
+	simulator := gobls.NewSimulator()
+	simulator.LoadImage(asciiImage(
+		"#.#",
+	))
+
+	manifest := gobls.NewManifest()
+	manifest.Pins["clk"] = gobls.Bus{{0, 0}}
+	manifest.Pins["we"] = gobls.Bus{{2, 0}}
+
+	exprs := []string{"clk changes", "clk rises && we", "clk falls || clk == 2"}
+	watches := make([]*gobls.Watch, len(exprs))
+	for i, expr := range exprs {
+		watch, err := gobls.NewWatch(expr, manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		watches[i] = watch
+		watch.Check(simulator)
+	}
+
+	// a clock toggling on every check with we held high
+	simulator.Set(2, 0, true)
+	counts := make([]int, len(watches))
+	for i := 1; i <= 10; i++ {
+		simulator.Set(0, 0, i%2 == 1)
+		for j, watch := range watches {
+			if watch.Check(simulator) {
+				counts[j]++
+			}
+		}
+	}
+
+	if counts[0] != 10 || counts[1] != 5 || counts[2] != 5 {
+		t.Errorf("fired %v times, want [10 5 5]", counts)
+	}
+}